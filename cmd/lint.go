@@ -0,0 +1,55 @@
+package main
+
+import (
+	"PrintLayout/pkg/printer"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// severityRank orders the severities accepted by --fail-on.
+var severityRank = map[string]int{"none": 0, "note": 1, "warning": 2, "error": 3}
+
+// runLint implements "pr lint": it checks a directory against the rules of a
+// layout policy. The exit code is 0 when no violation reaches the --fail-on
+// severity, 1 when one does, and 2 when the rules or the directory cannot be
+// read.
+func runLint(args []string) {
+	flags := flag.NewFlagSet("lint", flag.ExitOnError)
+	rulesFile := flags.String("rules", "layout-rules.yaml", "YAML file with the layout rules")
+	dir := flags.String("dir", ".", "Directory to check")
+	config := printer.Config{SortBy: "name", Order: "asc", IncludeHidden: true}
+	format := flags.String("format", "text", "Output format (text, json, sarif)")
+	failOn := flags.String("fail-on", "error", "Lowest severity that fails the check (error, warning, note, none)")
+	flags.Func("exclude", "Skip files/directories matching the pattern (can be specified multiple times)", func(pattern string) error {
+		config.ExcludePatterns = append(config.ExcludePatterns, pattern)
+		return nil
+	})
+	flags.Parse(args)
+
+	threshold, ok := severityRank[*failOn]
+	if !ok {
+		fmt.Fprintln(os.Stderr, "Error: unknown severity", *failOn, "(available: error, warning, note, none)")
+		os.Exit(2)
+	}
+
+	rules, err := printer.LoadRules(*rulesFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(2)
+	}
+	violations, err := printer.Lint(printer.DirFS(*dir), rules, config)
+	if err == nil {
+		err = printer.WriteViolations(os.Stdout, violations, rules, *format)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(2)
+	}
+
+	for _, v := range violations {
+		if threshold > 0 && severityRank[v.Severity] >= threshold {
+			os.Exit(1)
+		}
+	}
+}
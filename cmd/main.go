@@ -2,36 +2,134 @@ package main
 
 import (
 	"PrintLayout/pkg/printer"
+	"context"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"strings"
 )
 
 func main() {
+	// Subcommands have their own flags, except render and find which take
+	// the same flags as printing a directory
+	args := os.Args[1:]
+	render, find := false, false
+	if len(args) > 0 {
+		switch args[0] {
+		case "schema":
+			runSchema(args[1:])
+			return
+		case "lint":
+			runLint(args[1:])
+			return
+		case "scaffold":
+			runScaffold(args[1:])
+			return
+		case "render":
+			args, render = args[1:], true
+		case "find":
+			args, find = args[1:], true
+		}
+	}
+
 	config := printer.Config{}
+	var configFile string
 
 	// Define flags here:
-	flag.StringVar(&config.DirPath, "dir", ".", "Directory path to print the structure of")
+	flag.StringVar(&config.DirPath, "dir", ".", "Directory or archive path to print the structure of")
 	flag.StringVar(&config.OutputPath, "output", "", "Output file path")
 	flag.StringVar(&config.ExtFilter, "ext", "", "File extension filter (e.g., .go, .js)")
 	flag.BoolVar(&config.NoColor, "no-color", false, "Disable colorized output")
-	flag.StringVar(&config.OutputFormat, "format", "text", "Output format (text, json, xml, yaml)")
-	flag.StringVar(&config.DirColor, "dir-color", "blue", "Color for directories (e.g., blue, green, red)")
-	flag.StringVar(&config.FileColor, "file-color", "green", "Color for files (e.g., yellow, cyan, magenta)")
-	flag.StringVar(&config.ExecColor, "exec-color", "red", "Color for executables (e.g., red, green, blue)")
-	flag.StringVar(&config.SortBy, "sort-by", "name", "Sort by 'name', 'size', or 'time'")
+	flag.StringVar(&config.OutputFormat, "format", "text", "Output format (text, json, xml, yaml, markdown, html, list, csv, tsv, dot, mermaid, svg-treemap, html-treemap, svg-sunburst)")
+	flag.StringVar(&config.DirColor, "dir-color", "", "Color for directories, overriding the theme (e.g., blue, 208, '#5f87ff', bold+cyan)")
+	flag.StringVar(&config.FileColor, "file-color", "", "Color for files, overriding the theme (e.g., yellow, cyan, magenta)")
+	flag.StringVar(&config.ExecColor, "exec-color", "", "Color for executables, overriding the theme (e.g., red, green, blue)")
+	flag.StringVar(&config.Theme, "theme", "default", "Color theme (default, solarized, monokai, high-contrast, colorblind-safe) or path to a YAML theme file")
+	flag.BoolVar(&config.LSColors, "ls-colors", false, "Color entries according to the LS_COLORS environment variable")
+	flag.StringVar(&config.SortBy, "sort-by", "name", "Sort keys, comma-separated: 'name', 'natural' (or 'version', file2 before file10), 'size', 'time', 'ext' (e.g., ext,name)")
 	flag.StringVar(&config.Order, "order", "asc", "Sort order 'asc' or 'desc'")
+	flag.BoolVar(&config.DirsFirst, "dirs-first", false, "List directories before files")
+	flag.BoolVar(&config.FilesFirst, "files-first", false, "List files before directories")
+	flag.BoolVar(&config.IgnoreCase, "ignore-case", false, "Sort names without regard to case")
+	flag.StringVar(&config.Collate, "collate", "", "Sort names by the rules of a language (e.g., de, sv) or 'locale' for LC_ALL/LC_COLLATE/LANG, instead of byte order")
 	flag.BoolVar(&config.IncludeHidden, "hidden", false, "Include hidden files and directories")
 	flag.IntVar(&config.MaxDepth, "max-depth", -1, "Maximum depth of directory traversal")
+	flag.StringVar(&config.Style, "style", "unicode", "Tree drawing style (unicode, ascii, rounded, heavy, double, indent, custom)")
+	flag.BoolVar(&config.Icons, "icons", false, "Show a file-type icon before each entry (dropped with --no-color, NO_COLOR or --style ascii)")
+	flag.StringVar(&config.IconSet, "icon-set", "nerd", "Icons to use with --icons: 'nerd' (needs a Nerd Font) or 'emoji'")
+	flag.BoolVar(&config.Long, "long", false, "Show permissions, owner, group, size and modification time left of the tree")
+	flag.BoolVar(&config.Long, "l", false, "Shorthand for --long")
+	flag.StringVar(&config.TimeStyle, "time-style", "iso", "Time format for --long: 'iso', 'relative', 'locale' (LC_TIME/LC_ALL/LANG) or 'ls'")
+	flag.BoolVar(&config.HumanSizes, "human", false, "Print sizes in human-readable units (4.0K, 12M)")
+	flag.Func("columns", "Comma-separated --long columns (perms, owner, group, size, time)", func(columns string) error {
+		config.Columns = strings.Split(columns, ",")
+		return nil
+	})
+	flag.BoolVar(&config.FullPath, "full-path", false, "Print each entry's path relative to the root instead of its name")
+	flag.BoolVar(&config.AbsolutePaths, "absolute", false, "Print and record absolute paths")
+	flag.BoolVar(&config.NullTerminated, "0", false, "End --format list entries with NUL instead of newline, for xargs -0")
+	flag.Func("fields", "Comma-separated csv/tsv fields (path, type, size, mtime, mode, hash)", func(fields string) error {
+		config.Fields = strings.Split(fields, ",")
+		return nil
+	})
+	flag.BoolVar(&config.CollapseFiles, "collapse-files", false, "Show the files of each directory as a count in dot and mermaid output")
+	flag.IntVar(&config.GraphDepth, "graph-depth", 0, "Levels drawn in dot and mermaid output, deeper entries are counted (0 for all)")
+	flag.StringVar(&config.ChartColor, "chart-color", "type", "Color treemap and sunburst files by type or age")
+	flag.BoolVar(&config.LegacyOutput, "legacy-output", false, "Print the bare node tree of earlier versions in json, xml and yaml instead of the versioned document")
+	flag.StringVar(&config.Input, "input", "", "Render a layout previously printed with --format json, yaml or xml instead of reading --dir")
+	flag.BoolVar(&config.Stats, "stats", false, "Print a summary of sizes, extensions, largest, deepest, newest and oldest files, empty directories and executables")
+	flag.IntVar(&config.StatsTop, "stats-top", 10, "Number of entries in each ranked list of --stats")
+	flag.BoolVar(&config.LOC, "loc", false, "Count total, code, comment and blank lines of source files, summed per directory")
+	flag.StringVar(&config.MinSize, "min-size", "", "Only show files of at least this size (e.g., 100K, 1.5M)")
+	flag.StringVar(&config.MaxSize, "max-size", "", "Only show files of at most this size (e.g., 10M)")
+	flag.StringVar(&config.NewerThan, "newer-than", "", "Only show entries modified after an age or date (e.g., 2d, 12h, 2025-01-01)")
+	flag.StringVar(&config.OlderThan, "older-than", "", "Only show entries modified before an age or date (e.g., 1y, 2025-01-01)")
+	flag.StringVar(&config.Types, "type", "", "Only show entries of these types, comma-separated: f (file), d (directory), l (symlink), x (executable)")
+	flag.BoolVar(&config.Empty, "empty", false, "Only show empty files and directories")
+	flag.StringVar(&config.Contains, "contains", "", "Only show files with a line matching a regular expression, with the number of matching lines; binary files are skipped")
+	flag.BoolVar(&config.ContainsLine, "contains-line", false, "Show the first line matching --contains next to each file")
+	flag.IntVar(&config.FileLimit, "filelimit", 0, "Do not descend into directories with more than this many entries (0 for no limit)")
+	flag.IntVar(&config.MaxEntries, "max-entries", 0, "Show at most this many entries per directory, followed by a count and size of the rest (0 for all)")
+	flag.DurationVar(&config.Timeout, "timeout", 0, "Stop reading after this long and print the partial tree (e.g., 30s, 2m; 0 for no limit)")
+	flag.IntVar(&config.MaxEntriesTotal, "max-entries-total", 0, "Stop reading after this many entries and print the partial tree (0 for no limit)")
+	flag.StringVar(&config.Where, "where", "", "Only show entries matching an expression (e.g., 'ext in (\".go\", \".mod\") and size > 10KB and not path ~ \"vendor/\"')")
+	flag.StringVar(&configFile, "config", "", "YAML config file; command-line flags take precedence over its values")
+	flag.BoolVar(&config.IntoArchives, "into-archives", false, "Expand archives (.zip, .jar, .tar, .tar.gz, .tar.zst) found during the walk as directories")
 
 	// Add --exclude flag to specify exclusion patterns
 	flag.Func("exclude", "Exclude files/directories matching the pattern (can be specified multiple times)", func(pattern string) error {
 		config.ExcludePatterns = append(config.ExcludePatterns, pattern)
 		return nil
 	})
+	if find {
+		flag.StringVar(&config.FindMode, "mode", "glob", "Pattern syntax of pr find: 'glob', 'regex' or 'fuzzy'")
+	}
 
 	// Parse flags
-	flag.Parse()
+	positional := parseFlags(args)
+
+	// Load the config file, then parse the flags again so they override it
+	if configFile != "" {
+		config.ExcludePatterns = nil
+		if err := printer.LoadConfig(configFile, &config); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			os.Exit(1)
+		}
+		parseFlags(args)
+	}
+
+	if render && config.Input == "" {
+		fmt.Fprintln(os.Stderr, "Error: pr render needs --input.")
+		os.Exit(1)
+	}
+	if find {
+		if len(positional) != 1 {
+			fmt.Fprintln(os.Stderr, "Error: pr find needs one pattern.")
+			os.Exit(1)
+		}
+		config.Find = positional[0]
+	}
 
 	// Validate max-depth
 	if config.MaxDepth < -1 {
@@ -39,19 +137,25 @@ func main() {
 		return
 	}
 
-	printer.PrintProjectStructure(
-		config.DirPath,
-		config.OutputPath,
-		config.ExtFilter,
-		!config.NoColor,
-		config.OutputFormat,
-		config.DirColor,
-		config.FileColor,
-		config.ExecColor,
-		config.ExcludePatterns,
-		config.SortBy,
-		config.Order,
-		config.IncludeHidden,
-		config.MaxDepth,
-	)
+	// Ctrl-C stops the walk and prints the tree read so far
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := printer.HandleFlagsContext(ctx, config); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
+
+// parseFlags parses the command-line flags in args, which may come before or
+// after positional arguments, and returns the positional arguments.
+func parseFlags(args []string) []string {
+	var positional []string
+	for {
+		flag.CommandLine.Parse(args)
+		args = flag.Args()
+		if len(args) == 0 {
+			return positional
+		}
+		positional, args = append(positional, args[0]), args[1:]
+	}
 }
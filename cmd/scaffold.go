@@ -0,0 +1,52 @@
+package main
+
+import (
+	"PrintLayout/pkg/printer"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// runScaffold implements "pr scaffold": it creates the tree described by a
+// layout document or a text tree.
+func runScaffold(args []string) {
+	flags := flag.NewFlagSet("scaffold", flag.ExitOnError)
+	from := flags.String("from", "", "Layout to create: a json, yaml or xml document printed by pr, or a text tree")
+	into := flags.String("into", ".", "Directory to create the layout in")
+	opts := printer.ScaffoldOptions{Vars: map[string]string{}}
+	flags.BoolVar(&opts.DryRun, "dry-run", false, "Print what would be created without writing anything")
+	flags.BoolVar(&opts.NoOverwrite, "no-overwrite", false, "Fail without writing anything if a file already exists")
+	flags.BoolVar(&opts.Force, "force", false, "Replace existing files instead of keeping them")
+	flags.StringVar(&opts.Templates, "templates", "", "Directory of <path>.tmpl templates for the contents of new files")
+	flags.Func("var", "Template variable as name=value (can be specified multiple times)", func(v string) error {
+		name, value, ok := strings.Cut(v, "=")
+		if !ok {
+			return fmt.Errorf("expected name=value")
+		}
+		opts.Vars[name] = value
+		return nil
+	})
+	flags.Parse(args)
+
+	if *from == "" {
+		fmt.Fprintln(os.Stderr, "Error: pr scaffold needs --from.")
+		os.Exit(1)
+	}
+	if opts.NoOverwrite && opts.Force {
+		fmt.Fprintln(os.Stderr, "Error: --no-overwrite and --force cannot be used together.")
+		os.Exit(1)
+	}
+
+	tree, err := printer.LoadTree(*from)
+	if err == nil {
+		err = printer.Scaffold(tree, *into, opts, os.Stdout)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+	if opts.DryRun {
+		fmt.Println("Dry run: nothing was written.")
+	}
+}
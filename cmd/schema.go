@@ -0,0 +1,26 @@
+package main
+
+import (
+	"PrintLayout/pkg/printer"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// runSchema implements "pr schema": it prints the JSON Schema of the json and
+// yaml output, or the XSD of the xml output.
+func runSchema(args []string) {
+	flags := flag.NewFlagSet("schema", flag.ExitOnError)
+	format := flags.String("format", "json", "Output format the schema describes (json, yaml, xml)")
+	flags.Parse(args)
+
+	switch *format {
+	case "json", "yaml":
+		fmt.Print(printer.JSONSchema())
+	case "xml":
+		fmt.Print(printer.XMLSchema())
+	default:
+		fmt.Fprintln(os.Stderr, "Error: unknown format", *format, "(available: json, yaml, xml)")
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,326 @@
+package printer
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// archiveExtensions lists the suffixes recognised as browsable archives.
+var archiveExtensions = []string{".zip", ".jar", ".tar", ".tar.gz", ".tgz", ".tar.zst", ".tzst"}
+
+// isArchive reports whether the file name has a supported archive extension.
+func isArchive(name string) bool {
+	lower := strings.ToLower(name)
+	for _, ext := range archiveExtensions {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// OpenArchive reads the headers of the archive stored at name in fsys and
+// returns its layout as a read-only file system, which implements FS so
+// symlinks are shown with their targets. Only metadata is kept: the contents
+// of the members cannot be read back.
+func OpenArchive(fsys fs.FS, name string) (fs.FS, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	archive := newArchiveFS()
+	lower := strings.ToLower(name)
+
+	switch {
+	case strings.HasSuffix(lower, ".zip"), strings.HasSuffix(lower, ".jar"):
+		err = archive.readZip(f)
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		var gz *gzip.Reader
+		gz, err = gzip.NewReader(f)
+		if err != nil {
+			break
+		}
+		defer gz.Close()
+		err = archive.readTar(gz)
+	case strings.HasSuffix(lower, ".tar.zst"), strings.HasSuffix(lower, ".tzst"):
+		var zr *zstd.Decoder
+		zr, err = zstd.NewReader(f)
+		if err != nil {
+			break
+		}
+		defer zr.Close()
+		err = archive.readTar(zr)
+	case strings.HasSuffix(lower, ".tar"):
+		err = archive.readTar(f)
+	default:
+		err = fmt.Errorf("unsupported archive type")
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("reading archive %s: %w", name, err)
+	}
+	return archive, nil
+}
+
+// maxZipLinkTarget bounds the contents read as the target of a zip symlink.
+const maxZipLinkTarget = 4096
+
+// archiveFS is an in-memory FS built from archive headers.
+type archiveFS struct {
+	entries map[string]*archiveEntry
+	links   map[string]string // symlink targets by path
+}
+
+// archiveEntry holds the header metadata of a single archive member. It
+// implements both fs.FileInfo and fs.DirEntry.
+type archiveEntry struct {
+	name     string
+	size     int64
+	mode     fs.FileMode
+	modTime  time.Time
+	sys      any // the *tar.Header or *zip.FileHeader of the member
+	children []*archiveEntry
+}
+
+func newArchiveFS() *archiveFS {
+	return &archiveFS{
+		entries: map[string]*archiveEntry{
+			".": {name: ".", mode: fs.ModeDir | 0755},
+		},
+		links: map[string]string{},
+	}
+}
+
+// readZip adds the members of a zip (or jar) archive. The zip format needs
+// random access, so readers without io.ReaderAt are buffered in memory.
+func (a *archiveFS) readZip(f fs.File) error {
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	var r io.ReaderAt
+	size := info.Size()
+	if ra, ok := f.(io.ReaderAt); ok {
+		r = ra
+	} else {
+		data, err := io.ReadAll(f)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(data)
+		size = int64(len(data))
+	}
+
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return err
+	}
+	for _, file := range zr.File {
+		var target string
+		if file.Mode()&fs.ModeSymlink != 0 {
+			target = zipLinkTarget(file)
+		}
+		a.add(file.Name, file.FileInfo(), target)
+	}
+	return nil
+}
+
+// zipLinkTarget returns the target of a symlink member, which zip stores as
+// the member's contents, or "" when it cannot be read.
+func zipLinkTarget(file *zip.File) string {
+	rc, err := file.Open()
+	if err != nil {
+		return ""
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, maxZipLinkTarget))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// readTar adds the members of an uncompressed tar stream.
+func (a *archiveFS) readTar(r io.Reader) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag == tar.TypeXGlobalHeader {
+			continue
+		}
+		var target string
+		if hdr.Typeflag == tar.TypeSymlink {
+			target = hdr.Linkname
+		}
+		a.add(hdr.Name, hdr.FileInfo(), target)
+	}
+}
+
+// add records a member, creating any parent directories the archive does
+// not list explicitly, and the target of a symlink. Absolute names and names
+// that climb out of the archive with ".." are kept inside its root.
+func (a *archiveFS) add(name string, info fs.FileInfo, target string) {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if name == "" {
+		return
+	}
+	if target != "" {
+		a.links[name] = target
+	}
+
+	if entry, ok := a.entries[name]; ok {
+		// An explicit header for a directory we already created implicitly.
+		entry.size, entry.mode, entry.modTime, entry.sys = info.Size(), info.Mode(), info.ModTime(), info.Sys()
+		return
+	}
+
+	entry := &archiveEntry{
+		name:    path.Base(name),
+		size:    info.Size(),
+		mode:    info.Mode(),
+		modTime: info.ModTime(),
+		sys:     info.Sys(),
+	}
+	a.entries[name] = entry
+	parent := a.dir(path.Dir(name))
+	parent.children = append(parent.children, entry)
+}
+
+// dir returns the directory entry for name, creating it and its parents if
+// needed.
+func (a *archiveFS) dir(name string) *archiveEntry {
+	if entry, ok := a.entries[name]; ok {
+		return entry
+	}
+	entry := &archiveEntry{name: path.Base(name), mode: fs.ModeDir | 0755}
+	a.entries[name] = entry
+	parent := a.dir(path.Dir(name))
+	parent.children = append(parent.children, entry)
+	return entry
+}
+
+func (a *archiveFS) lookup(op, name string) (*archiveEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	entry, ok := a.entries[name]
+	if !ok {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return entry, nil
+}
+
+// Open implements fs.FS.
+func (a *archiveFS) Open(name string) (fs.File, error) {
+	entry, err := a.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+	return &archiveFile{entry: entry, path: name}, nil
+}
+
+// Stat implements fs.StatFS.
+func (a *archiveFS) Stat(name string) (fs.FileInfo, error) {
+	return a.lookup("stat", name)
+}
+
+// Lstat implements FS. Entries are never followed, so it is Stat.
+func (a *archiveFS) Lstat(name string) (fs.FileInfo, error) {
+	return a.Stat(name)
+}
+
+// ReadLink implements FS.
+func (a *archiveFS) ReadLink(name string) (string, error) {
+	if _, err := a.lookup("readlink", name); err != nil {
+		return "", err
+	}
+	target, ok := a.links[name]
+	if !ok {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+	return target, nil
+}
+
+// ReadDir implements fs.ReadDirFS.
+func (a *archiveFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entry, err := a.lookup("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	if !entry.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+	}
+	return entry.dirEntries(), nil
+}
+
+func (e *archiveEntry) dirEntries() []fs.DirEntry {
+	list := make([]fs.DirEntry, len(e.children))
+	for i, child := range e.children {
+		list[i] = child
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name() < list[j].Name() })
+	return list
+}
+
+func (e *archiveEntry) Name() string               { return e.name }
+func (e *archiveEntry) Size() int64                { return e.size }
+func (e *archiveEntry) Mode() fs.FileMode          { return e.mode }
+func (e *archiveEntry) ModTime() time.Time         { return e.modTime }
+func (e *archiveEntry) IsDir() bool                { return e.mode.IsDir() }
+func (e *archiveEntry) Sys() any                   { return e.sys }
+func (e *archiveEntry) Type() fs.FileMode          { return e.mode.Type() }
+func (e *archiveEntry) Info() (fs.FileInfo, error) { return e, nil }
+
+// archiveFile is an open handle on an archive member. Directories can be
+// listed; file contents are not available.
+type archiveFile struct {
+	entry  *archiveEntry
+	path   string
+	offset int
+}
+
+func (f *archiveFile) Stat() (fs.FileInfo, error) { return f.entry, nil }
+func (f *archiveFile) Close() error               { return nil }
+
+func (f *archiveFile) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: f.path, Err: errors.New("archive member contents are not available")}
+}
+
+// ReadDir implements fs.ReadDirFile.
+func (f *archiveFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	if !f.entry.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: f.path, Err: errors.New("not a directory")}
+	}
+	list := f.entry.dirEntries()[f.offset:]
+	if n > 0 {
+		if len(list) == 0 {
+			return nil, io.EOF
+		}
+		if n < len(list) {
+			list = list[:n]
+		}
+	}
+	f.offset += len(list)
+	return list, nil
+}
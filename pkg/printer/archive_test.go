@@ -0,0 +1,203 @@
+package printer
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// archiveMembers is the layout written into every test archive. Directory
+// "src/" is listed explicitly, "docs/" only implicitly through its file.
+// The body of a symlink is its target.
+var archiveMembers = []struct {
+	name    string
+	mode    int64
+	body    string
+	symlink bool
+}{
+	{"src/", 0755, "", false},
+	{"src/main.go", 0644, "package main\n", false},
+	{"bin/run.sh", 0755, "#!/bin/sh\n", false},
+	{"bin/start", 0777, "run.sh", true},
+	{"docs/README.md", 0644, "# docs\n", false},
+}
+
+const expectedArchiveTree = "├── bin/\n" +
+	"│   ├── run.sh\n" +
+	"│   └── start -> run.sh\n" +
+	"├── docs/\n" +
+	"│   └── README.md\n" +
+	"└── src/\n" +
+	"    └── main.go\n" +
+	"\n3 directories, 4 files\n"
+
+// TestArchiveRoot tests pointing the walk directly at an archive file.
+func TestArchiveRoot(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	for _, name := range []string{"release.zip", "release.jar", "release.tar", "release.tar.gz", "release.tar.zst"} {
+		t.Run(name, func(t *testing.T) {
+			archivePath := filepath.Join(tmpDir, name)
+			createTestArchive(t, archivePath)
+
+			output := captureOutput(func() {
+				HandleFlags(Config{DirPath: archivePath, NoColor: true, OutputFormat: "text", SortBy: "name", Order: "asc", MaxDepth: -1})
+			})
+
+			expected := name + "/\n" + expectedArchiveTree
+			if output != expected {
+				t.Errorf("Unexpected output:\nGot:\n%s\nExpected:\n%s", output, expected)
+			}
+		})
+	}
+}
+
+// TestArchiveMetadata tests that sizes, modes and times come from the headers.
+func TestArchiveMetadata(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "release.tar.gz")
+	createTestArchive(t, archivePath)
+
+	archive, err := OpenArchive(DirFS(filepath.Dir(archivePath)), filepath.Base(archivePath))
+	if err != nil {
+		t.Fatalf("OpenArchive failed: %v", err)
+	}
+
+	tree, err := BuildTree(archive, Config{SortBy: "name", Order: "asc", MaxDepth: -1})
+	if err != nil {
+		t.Fatalf("BuildTree failed: %v", err)
+	}
+
+	run := tree.Children[0].Children[0]
+	if run.Name != "run.sh" {
+		t.Fatalf("Unexpected first file: %s", run.Name)
+	}
+	if !isExecutable(run.info) {
+		t.Errorf("Expected run.sh to be executable, mode is %v", run.info.Mode())
+	}
+	if run.info.Size() != int64(len("#!/bin/sh\n")) {
+		t.Errorf("Unexpected size for run.sh: %d", run.info.Size())
+	}
+	if !run.info.ModTime().Equal(testArchiveTime) {
+		t.Errorf("Unexpected mtime for run.sh: %v", run.info.ModTime())
+	}
+}
+
+// TestIntoArchives tests expanding archives found during a normal walk.
+func TestIntoArchives(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(tmpDir, "dist"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	createTestArchive(t, filepath.Join(tmpDir, "dist", "app.zip"))
+
+	config := Config{DirPath: tmpDir, NoColor: true, OutputFormat: "text", SortBy: "name", Order: "asc", MaxDepth: -1}
+
+	t.Run("Disabled", func(t *testing.T) {
+		output := captureOutput(func() { HandleFlags(config) })
+
+		expected := filepath.Base(tmpDir) + "/\n" +
+			"└── dist/\n" +
+			"    └── app.zip\n" +
+			"\n1 directories, 1 files\n"
+		if output != expected {
+			t.Errorf("Unexpected output:\nGot:\n%s\nExpected:\n%s", output, expected)
+		}
+	})
+
+	t.Run("Enabled", func(t *testing.T) {
+		config := config
+		config.IntoArchives = true
+		config.ExtFilter = ".go"
+		output := captureOutput(func() { HandleFlags(config) })
+
+		expected := filepath.Base(tmpDir) + "/\n" +
+			"└── dist/\n" +
+			"    └── app.zip/\n" +
+			"        ├── bin/\n" +
+			"        ├── docs/\n" +
+			"        └── src/\n" +
+			"            └── main.go\n" +
+			"\n5 directories, 1 files\n"
+		if output != expected {
+			t.Errorf("Unexpected output:\nGot:\n%s\nExpected:\n%s", output, expected)
+		}
+	})
+}
+
+var testArchiveTime = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// createTestArchive writes archiveMembers into an archive whose type is
+// chosen by the extension of path.
+func createTestArchive(tb testing.TB, path string) {
+	f, err := os.Create(path)
+	if err != nil {
+		tb.Fatalf("Failed to create archive: %v", err)
+	}
+	defer f.Close()
+
+	if strings.HasSuffix(path, ".zip") || strings.HasSuffix(path, ".jar") {
+		zw := zip.NewWriter(f)
+		for _, m := range archiveMembers {
+			hdr := &zip.FileHeader{Name: m.name, Method: zip.Deflate, Modified: testArchiveTime}
+			hdr.SetMode(os.FileMode(m.mode))
+			switch {
+			case strings.HasSuffix(m.name, "/"):
+				hdr.SetMode(os.ModeDir | os.FileMode(m.mode))
+			case m.symlink:
+				hdr.SetMode(os.ModeSymlink | os.FileMode(m.mode))
+			}
+			w, err := zw.CreateHeader(hdr)
+			if err != nil {
+				tb.Fatalf("Failed to write zip header: %v", err)
+			}
+			io.WriteString(w, m.body)
+		}
+		if err := zw.Close(); err != nil {
+			tb.Fatalf("Failed to close zip: %v", err)
+		}
+		return
+	}
+
+	var w io.Writer = f
+	switch {
+	case strings.HasSuffix(path, ".tar.gz"):
+		gz := gzip.NewWriter(f)
+		defer gz.Close()
+		w = gz
+	case strings.HasSuffix(path, ".tar.zst"):
+		zw, err := zstd.NewWriter(f)
+		if err != nil {
+			tb.Fatalf("Failed to create zstd writer: %v", err)
+		}
+		defer zw.Close()
+		w = zw
+	}
+
+	tw := tar.NewWriter(w)
+	for _, m := range archiveMembers {
+		hdr := &tar.Header{Name: m.name, Mode: m.mode, Size: int64(len(m.body)), ModTime: testArchiveTime, Typeflag: tar.TypeReg}
+		switch {
+		case strings.HasSuffix(m.name, "/"):
+			hdr.Typeflag = tar.TypeDir
+		case m.symlink:
+			hdr.Typeflag, hdr.Linkname, hdr.Size = tar.TypeSymlink, m.body, 0
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			tb.Fatalf("Failed to write tar header: %v", err)
+		}
+		if !m.symlink {
+			io.WriteString(tw, m.body)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		tb.Fatalf("Failed to close tar: %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package printer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrMaxEntriesTotal is returned with the partial tree of a walk that
+// reached Config.MaxEntriesTotal.
+var ErrMaxEntriesTotal = errors.New("maximum number of entries reached")
+
+// Reasons for a Partial tree.
+const (
+	partialTimeout         = "timeout"
+	partialCanceled        = "canceled"
+	partialMaxEntriesTotal = "max_entries_total"
+)
+
+// Partial records why a walk stopped before reading the whole tree.
+type Partial struct {
+	Reason  string `json:"reason" yaml:"reason" xml:"reason,attr"`    // "timeout", "canceled" or "max_entries_total"
+	Entries int    `json:"entries" yaml:"entries" xml:"entries,attr"` // entries read before the walk stopped
+}
+
+// newPartial describes the error that stopped a walk after entries entries.
+func newPartial(err error, entries int) *Partial {
+	partial := &Partial{Reason: partialCanceled, Entries: entries}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		partial.Reason = partialTimeout
+	case errors.Is(err, ErrMaxEntriesTotal):
+		partial.Reason = partialMaxEntriesTotal
+	}
+	return partial
+}
+
+// String describes the partial tree for the text output.
+func (p *Partial) String() string {
+	entries := plural(p.Entries, "entry", "entries")
+	switch p.Reason {
+	case partialTimeout:
+		return fmt.Sprintf("partial tree: timed out after %s", entries)
+	case partialMaxEntriesTotal:
+		return fmt.Sprintf("partial tree: stopped at the limit of %s", entries)
+	}
+	return fmt.Sprintf("partial tree: canceled after %s", entries)
+}
+
+// visit counts an entry against Config.MaxEntriesTotal and reports whether
+// the walk goes on.
+func (w *walker) visit() bool {
+	if w.stopped() {
+		return false
+	}
+	if w.config.MaxEntriesTotal > 0 && w.visited >= w.config.MaxEntriesTotal {
+		w.stop = ErrMaxEntriesTotal
+		return false
+	}
+	w.visited++
+	return true
+}
+
+// stopped reports whether the walk was canceled, timed out or ran out of
+// entries.
+func (w *walker) stopped() bool {
+	if w.stop == nil {
+		w.stop = w.ctx.Err()
+	}
+	return w.stop != nil
+}
+
+// interruptible returns the result of f, or the error of ctx when it is done
+// first. Reads of a hung file system, such as a stale NFS mount, cannot be
+// interrupted, so f is then left to finish in the background.
+func interruptible[T any](ctx context.Context, f func() (T, error)) (T, error) {
+	if ctx.Done() == nil {
+		return f()
+	}
+
+	type result struct {
+		value T
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		value, err := f()
+		done <- result{value, err}
+	}()
+	select {
+	case r := <-done:
+		return r.value, r.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
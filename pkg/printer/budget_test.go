@@ -0,0 +1,163 @@
+package printer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// hangFS is a file system whose "slow" directory and "slow.go" file cannot
+// be read until release is closed, like a stale NFS mount.
+type hangFS struct {
+	fstest.MapFS
+	release chan struct{}
+}
+
+func (h hangFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if name == "slow" {
+		<-h.release
+	}
+	return h.MapFS.ReadDir(name)
+}
+
+func (h hangFS) Open(name string) (fs.File, error) {
+	if name == "slow.go" {
+		<-h.release
+	}
+	return h.MapFS.Open(name)
+}
+
+// budgetFS returns a tree with a directory that hangs.
+func budgetFS() hangFS {
+	return hangFS{
+		MapFS: fstest.MapFS{
+			"a.txt":        {},
+			"b/c.txt":      {},
+			"b/d.txt":      {},
+			"slow/e.txt":   {},
+			"z/after.txt":  {},
+			"z/after2.txt": {},
+		},
+		release: make(chan struct{}),
+	}
+}
+
+// TestMaxEntriesTotal tests that the walk stops after the budget of entries
+// and the partial tree is marked.
+func TestMaxEntriesTotal(t *testing.T) {
+	fsys := budgetFS()
+	close(fsys.release)
+
+	config := Config{SortBy: "name", Order: "asc", MaxDepth: -1, MaxEntriesTotal: 4}
+	tree, err := BuildTree(fsys, config)
+	if !errors.Is(err, ErrMaxEntriesTotal) || !strings.Contains(err.Error(), "walk stopped after 4 entries") {
+		t.Errorf("Expected the entry limit error, got %v", err)
+	}
+	if got, expected := listTree(tree), "a.txt b/ b/c.txt b/d.txt"; got != expected {
+		t.Errorf("got %q, expected %q", got, expected)
+	}
+
+	output := getTreeOutput(tree, textOptions{colors: plainPalette, style: treeStyles["ascii"]})
+	if !strings.HasSuffix(output, "1 directories, 3 files\n[partial tree: stopped at the limit of 4 entries]\n") {
+		t.Errorf("Expected the partial tree marker, got:\n%s", output)
+	}
+
+	data, _ := json.Marshal(newDocument(tree, ".", config))
+	for _, expected := range []string{`"max_entries_total":4`, `"partial":{"reason":"max_entries_total","entries":4}`} {
+		if !strings.Contains(string(data), expected) {
+			t.Errorf("Expected %s in %s", expected, data)
+		}
+	}
+
+	// A complete walk has no marker
+	config.MaxEntriesTotal = 9
+	if tree, err = BuildTree(fsys, config); err != nil || tree.partial != nil {
+		t.Errorf("Expected a complete tree, got %v, %+v", err, tree.partial)
+	}
+}
+
+// TestWalkTimeout tests that a hung directory does not block the walk past
+// its deadline.
+func TestWalkTimeout(t *testing.T) {
+	fsys := budgetFS()
+	defer close(fsys.release)
+
+	start := time.Now()
+	tree, err := BuildTree(fsys, Config{SortBy: "name", Order: "asc", MaxDepth: -1, Timeout: 50 * time.Millisecond})
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("The walk took %v", elapsed)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected a deadline error, got %v", err)
+	}
+	if got, expected := listTree(tree), "a.txt b/ b/c.txt b/d.txt slow/"; got != expected {
+		t.Errorf("got %q, expected %q", got, expected)
+	}
+	if tree.partial == nil || tree.partial.String() != "partial tree: timed out after 5 entries" {
+		t.Errorf("Unexpected partial marker %+v", tree.partial)
+	}
+}
+
+// TestTimeoutFileReads tests that hung reads of files stop at the deadline.
+func TestTimeoutFileReads(t *testing.T) {
+	fsys := hangFS{
+		MapFS: fstest.MapFS{
+			"a.go":    {Data: []byte("package a\n")},
+			"slow.go": {Data: []byte("package slow\n")},
+		},
+		release: make(chan struct{}),
+	}
+	defer close(fsys.release)
+
+	// Lines of code are counted while walking
+	tree, err := BuildTree(fsys, Config{SortBy: "name", Order: "asc", MaxDepth: -1, LOC: true, Timeout: 50 * time.Millisecond})
+	if !errors.Is(err, context.DeadlineExceeded) || tree == nil || tree.partial == nil {
+		t.Fatalf("Expected a timed out tree, got %v", err)
+	}
+	if got, expected := listTree(tree), "a.go slow.go"; got != expected {
+		t.Errorf("got %q, expected %q", got, expected)
+	}
+
+	// Hashes are read when printing
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	tree, _ = BuildTree(fsys, Config{SortBy: "name", Order: "asc", MaxDepth: -1})
+	output, err := getListOutput(ctx, tree, Config{OutputFormat: "csv", Fields: []string{"path", "hash"}})
+	if err != nil {
+		t.Fatalf("getListOutput failed: %v", err)
+	}
+	if !strings.HasSuffix(output, "\nslow.go,\n") {
+		t.Errorf("Expected no hash for the hung file, got:\n%s", output)
+	}
+}
+
+// TestBuildTreeContext tests that canceling the context stops the walk.
+func TestBuildTreeContext(t *testing.T) {
+	fsys := budgetFS()
+	defer close(fsys.release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	tree, err := BuildTreeContext(ctx, fsys, Config{SortBy: "name", Order: "asc", MaxDepth: -1})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected a canceled error, got %v", err)
+	}
+	if tree == nil || tree.partial == nil || tree.partial.Reason != "canceled" {
+		t.Fatalf("Expected a partial tree, got %+v", tree)
+	}
+
+	output := captureOutput(func() { PrintFSContext(ctx, fsys, Config{OutputFormat: "text", NoColor: true, MaxDepth: -1}) })
+	if output != "Error traversing directory: context canceled\n" {
+		t.Errorf("Expected the root not to be read after cancellation, got %q", output)
+	}
+
+	output = captureOutput(func() { HandleFlagsContext(ctx, Config{DirPath: t.TempDir(), OutputFormat: "text", MaxDepth: -1}) })
+	if output != "Error traversing directory: context canceled\n" {
+		t.Errorf("Expected HandleFlagsContext to stop, got %q", output)
+	}
+}
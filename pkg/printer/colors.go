@@ -0,0 +1,353 @@
+package printer
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+var colorMap = map[string]color.Attribute{
+	"black":          color.FgBlack,
+	"red":            color.FgRed,
+	"green":          color.FgGreen,
+	"yellow":         color.FgYellow,
+	"blue":           color.FgBlue,
+	"magenta":        color.FgMagenta,
+	"cyan":           color.FgCyan,
+	"white":          color.FgWhite,
+	"gray":           color.FgHiBlack,
+	"grey":           color.FgHiBlack,
+	"bright-red":     color.FgHiRed,
+	"bright-green":   color.FgHiGreen,
+	"bright-yellow":  color.FgHiYellow,
+	"bright-blue":    color.FgHiBlue,
+	"bright-magenta": color.FgHiMagenta,
+	"bright-cyan":    color.FgHiCyan,
+	"bright-white":   color.FgHiWhite,
+}
+
+var attributeMap = map[string]color.Attribute{
+	"bold":      color.Bold,
+	"dim":       color.Faint,
+	"italic":    color.Italic,
+	"underline": color.Underline,
+	"blink":     color.BlinkSlow,
+	"reverse":   color.ReverseVideo,
+}
+
+// parseColor parses a color spec made of tokens separated by "+" or ",".
+// A token is a color name from colorMap, a 256-color index (0-255), a
+// truecolor hex value (#rgb or #rrggbb) or an attribute from attributeMap,
+// e.g. "bold+#5f87ff" or "underline,208". An empty spec means no color.
+func parseColor(spec string) (*color.Color, error) {
+	tokens := strings.FieldsFunc(spec, func(r rune) bool { return r == '+' || r == ',' })
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	c := color.New()
+	for _, token := range tokens {
+		token = strings.ToLower(strings.TrimSpace(token))
+		if attr, ok := colorMap[token]; ok {
+			c.Add(attr)
+			continue
+		}
+		if attr, ok := attributeMap[token]; ok {
+			c.Add(attr)
+			continue
+		}
+		if strings.HasPrefix(token, "#") {
+			r, g, b, err := parseHexColor(token)
+			if err != nil {
+				return nil, err
+			}
+			c.AddRGB(r, g, b)
+			continue
+		}
+		if n, err := strconv.Atoi(token); err == nil && n >= 0 && n <= 255 {
+			c.Add(38, 5, color.Attribute(n))
+			continue
+		}
+		return nil, fmt.Errorf("unknown color %q in %q", token, spec)
+	}
+	return c, nil
+}
+
+// parseHexColor parses "#rgb" or "#rrggbb".
+func parseHexColor(hex string) (int, int, int, error) {
+	digits := hex[1:]
+	if len(digits) == 3 {
+		digits = string([]byte{digits[0], digits[0], digits[1], digits[1], digits[2], digits[2]})
+	}
+	if len(digits) != 6 {
+		return 0, 0, 0, fmt.Errorf("invalid hex color %q", hex)
+	}
+	v, err := strconv.ParseUint(digits, 16, 32)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid hex color %q", hex)
+	}
+	return int(v >> 16), int(v >> 8 & 0xff), int(v & 0xff), nil
+}
+
+// getColorFunc returns a color function for a color spec, see parseColor.
+// The function always emits escape codes; whether to colorize at all is
+// decided once by colorEnabled.
+func getColorFunc(spec string) (func(a ...interface{}) string, error) {
+	c, err := parseColor(spec)
+	if err != nil || c == nil {
+		return fmt.Sprint, err
+	}
+	c.EnableColor()
+	return c.SprintFunc(), nil
+}
+
+// lsColors holds the entries of an LS_COLORS value. Type keys are the
+// two-letter dircolors codes ("di", "ln", "ex", ...); suffixes come from
+// "*.ext" style patterns and are matched case-insensitively.
+type lsColors struct {
+	types      map[string]func(a ...interface{}) string
+	suffixes   map[string]func(a ...interface{}) string
+	linkTarget bool // "ln=target": color links like the file they point to
+}
+
+// parseLSColors parses a colon-separated LS_COLORS value. Malformed entries
+// are ignored, as ls does.
+func parseLSColors(value string) *lsColors {
+	ls := &lsColors{
+		types:    map[string]func(a ...interface{}) string{},
+		suffixes: map[string]func(a ...interface{}) string{},
+	}
+
+	for _, entry := range strings.Split(value, ":") {
+		key, sgr, ok := strings.Cut(entry, "=")
+		if !ok || key == "" {
+			continue
+		}
+		if key == "ln" && sgr == "target" {
+			ls.linkTarget = true
+			continue
+		}
+
+		colorFunc, ok := sgrColorFunc(sgr)
+		if !ok {
+			continue
+		}
+		if strings.HasPrefix(key, "*") {
+			ls.suffixes[strings.ToLower(key[1:])] = colorFunc
+		} else {
+			ls.types[key] = colorFunc
+		}
+	}
+	return ls
+}
+
+// sgrColorFunc turns an SGR parameter list such as "01;34" or "38;5;208"
+// into a color function.
+func sgrColorFunc(sgr string) (func(a ...interface{}) string, bool) {
+	var attrs []color.Attribute
+	for _, part := range strings.Split(sgr, ";") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, false
+		}
+		if n != 0 {
+			attrs = append(attrs, color.Attribute(n))
+		}
+	}
+	if len(attrs) == 0 {
+		return fmt.Sprint, true
+	}
+	c := color.New(attrs...)
+	c.EnableColor()
+	return c.SprintFunc(), true
+}
+
+// typeKey returns the dircolors type code of a node, or "" for regular
+// files without a special mode.
+func (ls *lsColors) typeKey(node *Node) string {
+	if node.info == nil {
+		if node.IsDir {
+			return "di"
+		}
+		return ""
+	}
+
+	mode := node.info.Mode()
+	switch {
+	case node.IsDir && mode&fs.ModeDir == 0:
+		return "di" // an archive expanded with --into-archives
+	case mode&fs.ModeSymlink != 0:
+		if node.brokenLink {
+			return "or"
+		}
+		return "ln"
+	case mode&fs.ModeNamedPipe != 0:
+		return "pi"
+	case mode&fs.ModeSocket != 0:
+		return "so"
+	case mode&fs.ModeDevice != 0 && mode&fs.ModeCharDevice != 0:
+		return "cd"
+	case mode&fs.ModeDevice != 0:
+		return "bd"
+	case mode.IsDir():
+		sticky := mode&fs.ModeSticky != 0
+		otherWritable := mode.Perm()&0002 != 0
+		switch {
+		case sticky && otherWritable:
+			return "tw"
+		case otherWritable:
+			return "ow"
+		case sticky:
+			return "st"
+		}
+		return "di"
+	case mode&fs.ModeSetuid != 0:
+		return "su"
+	case mode&fs.ModeSetgid != 0:
+		return "sg"
+	case isExecutable(node.info):
+		return "ex"
+	}
+	return ""
+}
+
+// colorFor returns the LS_COLORS color function for a node, or false when
+// LS_COLORS has no entry that applies.
+func (ls *lsColors) colorFor(node *Node) (func(a ...interface{}) string, bool) {
+	key := ls.typeKey(node)
+	switch key {
+	case "ln":
+		if ls.linkTarget {
+			key = ""
+		}
+	case "tw", "ow", "st":
+		// Fall back from the specialised directory codes to "di", as ls does
+		if _, ok := ls.types[key]; !ok {
+			key = "di"
+		}
+	}
+
+	if f, ok := ls.types[key]; ok && key != "" {
+		return f, true
+	}
+	switch key {
+	case "", "su", "sg", "ex":
+		// Regular files are matched by suffix, then fall back to "fi"
+	default:
+		return nil, false
+	}
+
+	name := strings.ToLower(node.Name)
+	best := -1
+	var found func(a ...interface{}) string
+	for suffix, f := range ls.suffixes {
+		if len(suffix) > best && strings.HasSuffix(name, suffix) {
+			best, found = len(suffix), f
+		}
+	}
+	if found != nil {
+		return found, true
+	}
+
+	f, ok := ls.types["fi"]
+	return f, ok
+}
+
+// palette decides the color of each element in the text output.
+type palette struct {
+	dir     func(a ...interface{}) string
+	file    func(a ...interface{}) string
+	exec    func(a ...interface{}) string
+	symlink func(a ...interface{}) string
+	branch  func(a ...interface{}) string
+	summary func(a ...interface{}) string
+	size    func(a ...interface{}) string
+	date    func(a ...interface{}) string
+	match   func(a ...interface{}) string
+	ls      *lsColors // nil unless LS_COLORS is in use
+}
+
+// plainPalette leaves every element uncolored.
+var plainPalette = &palette{
+	dir:     fmt.Sprint,
+	file:    fmt.Sprint,
+	exec:    fmt.Sprint,
+	symlink: fmt.Sprint,
+	branch:  fmt.Sprint,
+	summary: fmt.Sprint,
+	size:    fmt.Sprint,
+	date:    fmt.Sprint,
+	match:   fmt.Sprint,
+}
+
+// newPalette builds the palette for the config from its theme, with the
+// directory, file and executable colors overriding the theme when set.
+// Unknown color names are an error even when colors are disabled or the
+// format has none, so typos do not go unnoticed; ValidateConfig checks them
+// before the walk.
+func newPalette(config Config) (*palette, error) {
+	theme, err := loadTheme(config.Theme)
+	if err != nil {
+		return nil, err
+	}
+	if config.DirColor != "" {
+		theme.Directory = config.DirColor
+	}
+	if config.FileColor != "" {
+		theme.File = config.FileColor
+	}
+	if config.ExecColor != "" {
+		theme.Executable = config.ExecColor
+	}
+
+	p := &palette{}
+	for _, c := range []struct {
+		name string
+		spec string
+		dst  *func(a ...interface{}) string
+	}{
+		{"directory", theme.Directory, &p.dir},
+		{"file", theme.File, &p.file},
+		{"executable", theme.Executable, &p.exec},
+		{"symlink", theme.Symlink, &p.symlink},
+		{"branch", theme.Branch, &p.branch},
+		{"summary", theme.Summary, &p.summary},
+		{"size", theme.Size, &p.size},
+		{"date", theme.Date, &p.date},
+		{"match", theme.Match, &p.match},
+	} {
+		if *c.dst, err = getColorFunc(c.spec); err != nil {
+			return nil, fmt.Errorf("%s color: %w", c.name, err)
+		}
+	}
+
+	if config.LSColors {
+		if value := os.Getenv("LS_COLORS"); value != "" {
+			p.ls = parseLSColors(value)
+		}
+	}
+	return p, nil
+}
+
+// colorFor returns the color function for a node. LS_COLORS entries take
+// precedence over the theme.
+func (p *palette) colorFor(node *Node) func(a ...interface{}) string {
+	if p.ls != nil {
+		if f, ok := p.ls.colorFor(node); ok {
+			return f
+		}
+	}
+	switch {
+	case node.IsDir:
+		return p.dir
+	case node.info != nil && node.info.Mode()&fs.ModeSymlink != 0:
+		return p.symlink
+	case node.info != nil && isExecutable(node.info):
+		return p.exec
+	}
+	return p.file
+}
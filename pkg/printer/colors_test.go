@@ -0,0 +1,105 @@
+package printer
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// TestParseColor tests the accepted color specs.
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		spec     string
+		expected string
+	}{
+		{"blue", "\x1b[34mx\x1b["},
+		{"bright-cyan", "\x1b[96mx\x1b["},
+		{"bold+blue", "\x1b[1;34mx\x1b["},
+		{"208", "\x1b[38;5;208mx\x1b["},
+		{"#5f87ff", "\x1b[38;2;95;135;255mx\x1b["},
+		{"underline, #fff", "\x1b[4;38;2;255;255;255mx\x1b["},
+		{"", "x"},
+	}
+
+	for _, tt := range tests {
+		colorFunc, err := getColorFunc(tt.spec)
+		if err != nil {
+			t.Errorf("getColorFunc(%q) failed: %v", tt.spec, err)
+			continue
+		}
+		// Only the opening sequence is checked; the reset codes are up to the color package
+		if got := colorFunc("x"); !strings.HasPrefix(got, tt.expected) || (tt.spec == "" && got != "x") {
+			t.Errorf("getColorFunc(%q) = %q, expected %q", tt.spec, got, tt.expected)
+		}
+	}
+
+	for _, spec := range []string{"bleu", "256", "#12345", "#gggggg", "bold+purple"} {
+		if _, err := getColorFunc(spec); err == nil {
+			t.Errorf("Expected an error for color %q", spec)
+		}
+	}
+}
+
+// TestUnknownColorIsAnError tests that a typo in a color name stops the
+// text output instead of silently disabling the color.
+func TestUnknownColorIsAnError(t *testing.T) {
+	config := Config{NoColor: true, OutputFormat: "text", DirColor: "bleu", MaxDepth: -1}
+	output := captureOutput(func() { PrintFS(fstest.MapFS{"a": {}}, config) })
+
+	if !strings.HasPrefix(output, "Error: directory color: unknown color \"bleu\"") {
+		t.Errorf("Expected an unknown color error, got:\n%s", output)
+	}
+}
+
+// TestLSColors tests coloring entries from an LS_COLORS value.
+func TestLSColors(t *testing.T) {
+	t.Setenv("LS_COLORS", "di=01;34:ln=01;36:or=40;31:ex=01;32:tw=30;42:*.tar.gz=01;31:*.GZ=33:*.go=38;5;81:fi=0")
+
+	fsys := fstest.MapFS{
+		"src":         {Mode: fs.ModeDir | 0755},
+		"tmp":         {Mode: fs.ModeDir | fs.ModeSticky | 0777},
+		"run.sh":      {Mode: 0755},
+		"main.go":     {Mode: 0644},
+		"a.tar.gz":    {Mode: 0644},
+		"b.gz":        {Mode: 0644},
+		"notes.txt":   {Mode: 0644},
+		"link":        {Mode: fs.ModeSymlink | 0777, Data: []byte("main.go")},
+		"dangling":    {Mode: fs.ModeSymlink | 0777, Data: []byte("missing")},
+		"plain-dir/x": {},
+	}
+	config := Config{DirColor: "red", FileColor: "red", ExecColor: "red", LSColors: true, MaxDepth: -1}
+
+	tree, err := BuildTree(fsys, config)
+	if err != nil {
+		t.Fatalf("BuildTree failed: %v", err)
+	}
+	colors, err := newPalette(config)
+	if err != nil {
+		t.Fatalf("newPalette failed: %v", err)
+	}
+
+	expected := map[string]string{
+		"src":       "\x1b[1;34msrc",
+		"plain-dir": "\x1b[1;34mplain-dir",
+		"tmp":       "\x1b[30;42mtmp",
+		"run.sh":    "\x1b[1;32mrun.sh",
+		"main.go":   "\x1b[38;5;81mmain.go",
+		"a.tar.gz":  "\x1b[1;31ma.tar.gz",
+		"b.gz":      "\x1b[33mb.gz",
+		"notes.txt": "notes.txt",
+		"link":      "\x1b[1;36mlink",
+		"dangling":  "\x1b[40;31mdangling",
+	}
+	for _, child := range tree.Children {
+		want, ok := expected[child.Name]
+		if !ok {
+			t.Errorf("Unexpected entry %s", child.Name)
+			continue
+		}
+		got := colors.colorFor(child)(child.Name)
+		if !strings.HasPrefix(got, want) || (want == child.Name && got != want) {
+			t.Errorf("%s colored as %q, expected %q", child.Name, got, want)
+		}
+	}
+}
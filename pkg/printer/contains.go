@@ -0,0 +1,144 @@
+package printer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"runtime"
+	"strings"
+	"unicode/utf8"
+)
+
+// ContentHits are the lines of a file matched by Config.Contains.
+type ContentHits struct {
+	Count int    `json:"count" yaml:"count" xml:"count,attr"`                            // number of matching lines
+	Line  int    `json:"line,omitempty" yaml:"line,omitempty" xml:"line,attr,omitempty"` // number of the first matching line, with Config.ContainsLine
+	Text  string `json:"text,omitempty" yaml:"text,omitempty" xml:",chardata"`           // the first matching line, trimmed
+}
+
+// String formats the hits for the text output, e.g. "[3 matches]  12: func main() {".
+func (h *ContentHits) String() string {
+	s := "[" + plural(h.Count, "match", "matches") + "]"
+	if h.Line > 0 {
+		s += fmt.Sprintf("  %d: %s", h.Line, h.Text)
+	}
+	return s
+}
+
+const (
+	binarySniffLen   = 8000    // bytes checked for a NUL to detect binary files, as git does
+	maxContentLine   = 1 << 20 // longest line searched; the rest of a file after a longer one is skipped
+	maxHitTextLength = 120     // runes of the first matching line that are kept
+)
+
+// contentScanner searches file contents for Config.Contains. Files are read
+// by a fixed number of goroutines, each holding at most one line buffer, so
+// memory stays bounded however many files are searched.
+type contentScanner struct {
+	re    *regexp.Regexp
+	line  bool          // record the first matching line
+	slots chan struct{} // one per running scan
+}
+
+// newContentScanner compiles the Contains pattern of config, returning nil
+// when it is not set.
+func newContentScanner(config Config) (*contentScanner, error) {
+	if config.Contains == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(config.Contains)
+	if err != nil {
+		return nil, fmt.Errorf("--contains: %w", err)
+	}
+	return &contentScanner{
+		re:    re,
+		line:  config.ContainsLine,
+		slots: make(chan struct{}, max(4, runtime.NumCPU())),
+	}, nil
+}
+
+// scan starts searching the file name of fsys and returns a channel that
+// receives its hits, or nil when nothing matches. It blocks while all
+// scanners are busy, unless ctx is done, when the file is not searched and
+// the channel never receives.
+func (s *contentScanner) scan(ctx context.Context, fsys fs.FS, name string) <-chan *ContentHits {
+	result := make(chan *ContentHits, 1)
+	select {
+	case s.slots <- struct{}{}:
+	case <-ctx.Done():
+		return result
+	}
+	go func() {
+		defer func() { <-s.slots }()
+		result <- s.search(fsys, name)
+	}()
+	return result
+}
+
+// search returns the hits in the file name of fsys, or nil when the file has
+// none, cannot be read or is binary.
+func (s *contentScanner) search(fsys fs.FS, name string) *ContentHits {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	head, _ := reader.Peek(binarySniffLen)
+	if bytes.IndexByte(head, 0) >= 0 {
+		return nil
+	}
+
+	var hits *ContentHits
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(nil, maxContentLine)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Bytes()
+		if !s.re.Match(line) {
+			continue
+		}
+		if hits == nil {
+			hits = &ContentHits{}
+			if s.line {
+				hits.Line, hits.Text = n, trimHitText(line)
+			}
+		}
+		hits.Count++
+	}
+	return hits
+}
+
+// trimHitText returns a matching line without surrounding space, shortened
+// to maxHitTextLength runes and with invalid UTF-8 replaced.
+func trimHitText(line []byte) string {
+	text := strings.ToValidUTF8(strings.TrimSpace(string(line)), "�")
+	if utf8.RuneCountInString(text) <= maxHitTextLength {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:maxHitTextLength]) + "…"
+}
+
+// resolveScans waits for the scans started for the children of node and
+// removes the files without hits. Once ctx is done, files whose search has
+// not ended are removed too.
+func resolveScans(ctx context.Context, node *Node, scans map[*Node]<-chan *ContentHits) {
+	kept := node.Children[:0]
+	for _, child := range node.Children {
+		if scan, ok := scans[child]; ok {
+			select {
+			case child.hits = <-scan:
+			case <-ctx.Done():
+			}
+			if child.hits == nil {
+				continue
+			}
+		}
+		kept = append(kept, child)
+	}
+	node.Children = kept
+}
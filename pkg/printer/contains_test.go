@@ -0,0 +1,125 @@
+package printer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// containsFS returns a project with text, binary and empty files.
+func containsFS() fstest.MapFS {
+	return fstest.MapFS{
+		"main.go":          {Data: []byte("package main\n\n// TODO: flags\nfunc main() {\n\t// TODO: run\n}\n")},
+		"README.md":        {Data: []byte("# Project\n\nNothing to do.\n")},
+		"logo.png":         {Data: []byte("\x89PNG\r\n\x00\x00TODO")},
+		"empty.txt":        {},
+		"docs/notes.txt":   {Data: []byte("  TODO:   write the docs   \n")},
+		"docs/old/a.txt":   {Data: []byte("done\n")},
+		"link":             {Data: []byte("main.go"), Mode: fs.ModeSymlink | 0777},
+		"src/pkg/util.go":  {Data: []byte("package pkg\n\nfunc util() {} // TODO\n")},
+		"src/pkg/util.bin": {Data: append([]byte("TODO"), 0)},
+	}
+}
+
+// TestContains tests that only files with matching lines are shown, with
+// their ancestors and hit counts.
+func TestContains(t *testing.T) {
+	config := Config{SortBy: "name", Order: "asc", MaxDepth: -1, Contains: `TODO`}
+	tree, err := BuildTree(containsFS(), config)
+	if err != nil {
+		t.Fatalf("BuildTree failed: %v", err)
+	}
+	if got, expected := listTree(tree), "docs/ docs/notes.txt main.go src/ src/pkg/ src/pkg/util.go"; got != expected {
+		t.Errorf("got %q, expected %q", got, expected)
+	}
+
+	output := getTreeOutput(tree, textOptions{colors: plainPalette, style: treeStyles["ascii"]})
+	for _, expected := range []string{"notes.txt [1 match]\n", "main.go [2 matches]\n", "util.go [1 match]\n"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected %q in the output:\n%s", expected, output)
+		}
+	}
+
+	// With the first matching line, and counted lines only for the hits
+	config.ContainsLine, config.LOC, config.ExtFilter = true, true, ".go"
+	tree, err = BuildTree(containsFS(), config)
+	if err != nil {
+		t.Fatalf("BuildTree failed: %v", err)
+	}
+	output = getTreeOutput(tree, textOptions{colors: plainPalette, style: treeStyles["ascii"]})
+	if !strings.Contains(output, "main.go [Go: 6 lines, 3 code, 2 comment, 1 blank] [2 matches]  3: // TODO: flags\n") {
+		t.Errorf("Expected the first matching line, got:\n%s", output)
+	}
+	if tree.loc.Lines != 9 {
+		t.Errorf("Expected the root to count the lines of the two hits, got %d", tree.loc.Lines)
+	}
+}
+
+// TestContainsDocument tests the hits in structured output.
+func TestContainsDocument(t *testing.T) {
+	config := Config{SortBy: "name", Order: "asc", MaxDepth: -1, Contains: `(?i)todo:`, ContainsLine: true}
+	tree, err := BuildTree(containsFS(), config)
+	if err != nil {
+		t.Fatalf("BuildTree failed: %v", err)
+	}
+	data, err := json.Marshal(newDocument(tree, ".", config))
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	for _, expected := range []string{
+		`"contains":"(?i)todo:"`,
+		`"path":"docs/notes.txt","type":"file","size":28,"mode":"----------","hits":{"count":1,"line":1,"text":"TODO:   write the docs"}`,
+		`"hits":{"count":2,"line":3,"text":"// TODO: flags"}`,
+	} {
+		if !strings.Contains(string(data), expected) {
+			t.Errorf("Expected %s in %s", expected, data)
+		}
+	}
+}
+
+// TestContainsMany tests that the concurrent search keeps the walk order.
+func TestContainsMany(t *testing.T) {
+	fsys := fstest.MapFS{}
+	var expected []string
+	for i := 0; i < 300; i++ {
+		name := fmt.Sprintf("dir%d/file%03d.txt", i%3, i)
+		data := "nothing\n"
+		if i%7 == 0 {
+			data = fmt.Sprintf("line %d\nneedle\n", i)
+		}
+		fsys[name] = &fstest.MapFile{Data: []byte(data)}
+	}
+	for d := 0; d < 3; d++ {
+		expected = append(expected, fmt.Sprintf("dir%d/", d))
+		for i := d; i < 300; i += 3 {
+			if i%7 == 0 {
+				expected = append(expected, fmt.Sprintf("dir%d/file%03d.txt", d, i))
+			}
+		}
+	}
+
+	tree, err := BuildTree(fsys, Config{SortBy: "name", Order: "asc", MaxDepth: -1, Contains: "^needle$"})
+	if err != nil {
+		t.Fatalf("BuildTree failed: %v", err)
+	}
+	if got := listTree(tree); got != strings.Join(expected, " ") {
+		t.Errorf("got %q\nexpected %q", got, strings.Join(expected, " "))
+	}
+}
+
+// TestTrimHitText tests that long first lines are shortened.
+func TestTrimHitText(t *testing.T) {
+	long := strings.Repeat("é", maxHitTextLength+10)
+	if got := trimHitText([]byte("\t" + long)); got != strings.Repeat("é", maxHitTextLength)+"…" {
+		t.Errorf("Unexpected text %q", got)
+	}
+	if got := trimHitText([]byte("a\xffb ")); got != "a�b" {
+		t.Errorf("Unexpected text %q", got)
+	}
+	if _, err := BuildTree(containsFS(), Config{MaxDepth: -1, Contains: "("}); err == nil || !strings.Contains(err.Error(), "--contains: error parsing regexp") {
+		t.Errorf("Expected an invalid pattern error, got %v", err)
+	}
+}
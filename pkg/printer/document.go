@@ -0,0 +1,196 @@
+package printer
+
+import (
+	_ "embed"
+	"encoding/xml"
+	"time"
+)
+
+// SchemaVersion is the version of the Document shape printed by the json,
+// xml and yaml formats. It is increased whenever a field is removed or
+// changes meaning; new optional fields keep the version.
+const SchemaVersion = 1
+
+//go:embed schema/layout.schema.json
+var jsonSchema string
+
+//go:embed schema/layout.xsd
+var xmlSchema string
+
+// JSONSchema returns the JSON Schema of the json and yaml output.
+func JSONSchema() string { return jsonSchema }
+
+// XMLSchema returns the XSD of the xml output.
+func XMLSchema() string { return xmlSchema }
+
+// Document is the envelope of the structured output formats: the tree and
+// metadata about the scan that produced it.
+type Document struct {
+	XMLName       xml.Name  `json:"-" yaml:"-" xml:"layout"`
+	SchemaVersion int       `json:"schema_version" yaml:"schema_version" xml:"schema_version,attr"`
+	ScannedAt     time.Time `json:"scanned_at" yaml:"scanned_at" xml:"scanned_at,attr"`
+	Root          string    `json:"root" yaml:"root" xml:"root,attr"` // the scanned directory, archive or fs.FS path
+	Options       Options   `json:"options" yaml:"options" xml:"options"`
+	Counts        Counts    `json:"counts" yaml:"counts" xml:"counts"`
+	Partial       *Partial  `json:"partial,omitempty" yaml:"partial,omitempty" xml:"partial,omitempty"` // set when the walk stopped early
+	Summary       *Summary  `json:"summary,omitempty" yaml:"summary,omitempty" xml:"summary,omitempty"` // set with Config.Stats
+	Tree          *Entry    `json:"tree" yaml:"tree" xml:",any"`
+}
+
+// Options records the settings that decided which entries are in the tree.
+type Options struct {
+	ExtFilter     string   `json:"ext,omitempty" yaml:"ext,omitempty" xml:"ext,omitempty"`
+	Exclude       []string `json:"exclude,omitempty" yaml:"exclude,omitempty" xml:"exclude,omitempty"`
+	IncludeHidden bool     `json:"hidden" yaml:"hidden" xml:"hidden"`
+	MaxDepth      int      `json:"max_depth" yaml:"max_depth" xml:"max_depth"`
+	SortBy        string   `json:"sort_by" yaml:"sort_by" xml:"sort_by"`
+	Order         string   `json:"order" yaml:"order" xml:"order"`
+	DirsFirst     bool     `json:"dirs_first,omitempty" yaml:"dirs_first,omitempty" xml:"dirs_first,omitempty"`
+	FilesFirst    bool     `json:"files_first,omitempty" yaml:"files_first,omitempty" xml:"files_first,omitempty"`
+	IgnoreCase    bool     `json:"ignore_case,omitempty" yaml:"ignore_case,omitempty" xml:"ignore_case,omitempty"`
+	Collate       string   `json:"collate,omitempty" yaml:"collate,omitempty" xml:"collate,omitempty"`
+	IntoArchives  bool     `json:"into_archives" yaml:"into_archives" xml:"into_archives"`
+	MinSize       string   `json:"min_size,omitempty" yaml:"min_size,omitempty" xml:"min_size,omitempty"`
+	MaxSize       string   `json:"max_size,omitempty" yaml:"max_size,omitempty" xml:"max_size,omitempty"`
+	NewerThan     string   `json:"newer_than,omitempty" yaml:"newer_than,omitempty" xml:"newer_than,omitempty"`
+	OlderThan     string   `json:"older_than,omitempty" yaml:"older_than,omitempty" xml:"older_than,omitempty"`
+	Types         string   `json:"type,omitempty" yaml:"type,omitempty" xml:"type,omitempty"`
+	Empty         bool     `json:"empty,omitempty" yaml:"empty,omitempty" xml:"empty,omitempty"`
+	Where         string   `json:"where,omitempty" yaml:"where,omitempty" xml:"where,omitempty"`
+	Find          string   `json:"find,omitempty" yaml:"find,omitempty" xml:"find,omitempty"`
+	FindMode      string   `json:"find_mode,omitempty" yaml:"find_mode,omitempty" xml:"find_mode,omitempty"`
+	Contains      string   `json:"contains,omitempty" yaml:"contains,omitempty" xml:"contains,omitempty"`
+	FileLimit     int      `json:"filelimit,omitempty" yaml:"filelimit,omitempty" xml:"filelimit,omitempty"`
+	MaxEntries    int      `json:"max_entries,omitempty" yaml:"max_entries,omitempty" xml:"max_entries,omitempty"`
+	Timeout       string   `json:"timeout,omitempty" yaml:"timeout,omitempty" xml:"timeout,omitempty"` // e.g. 30s
+	MaxTotal      int      `json:"max_entries_total,omitempty" yaml:"max_entries_total,omitempty" xml:"max_entries_total,omitempty"`
+}
+
+// Counts are the numbers of entries below the root.
+type Counts struct {
+	Directories int `json:"directories" yaml:"directories" xml:"directories"`
+	Files       int `json:"files" yaml:"files" xml:"files"`
+}
+
+// Entry is a node of the Document tree. In XML the element name is the
+// entry type: <dir>, <file>, <symlink> or <other>.
+type Entry struct {
+	XMLName    xml.Name     `json:"-" yaml:"-"`
+	Name       string       `json:"name" yaml:"name" xml:"name,attr"`
+	Path       string       `json:"path" yaml:"path" xml:"path,attr"`
+	Type       string       `json:"type" yaml:"type" xml:"-"` // "dir", "file", "symlink" or "other"
+	Size       *int64       `json:"size,omitempty" yaml:"size,omitempty" xml:"size,attr,omitempty"`
+	ModTime    string       `json:"mod_time,omitempty" yaml:"mod_time,omitempty" xml:"mod_time,attr,omitempty"` // RFC 3339
+	Mode       string       `json:"mode,omitempty" yaml:"mode,omitempty" xml:"mode,attr,omitempty"`             // as printed by ls -l
+	LinkTarget string       `json:"link_target,omitempty" yaml:"link_target,omitempty" xml:"link_target,attr,omitempty"`
+	Error      string       `json:"error,omitempty" yaml:"error,omitempty" xml:"error,attr,omitempty"`        // why the directory could not be read
+	LOC        *LineCounts  `json:"loc,omitempty" yaml:"loc,omitempty" xml:"loc,omitempty"`                   // with --loc, for source files and directories
+	Hits       *ContentHits `json:"hits,omitempty" yaml:"hits,omitempty" xml:"hits,omitempty"`                // with --contains, for files
+	Truncated  *Truncation  `json:"truncated,omitempty" yaml:"truncated,omitempty" xml:"truncated,omitempty"` // with --filelimit or --max-entries, for directories with entries left out
+	Children   []*Entry     `json:"children,omitempty" yaml:"children,omitempty" xml:",any"`
+}
+
+// newDocument wraps the tree in a Document. root is recorded as given.
+func newDocument(tree *Node, root string, config Config) *Document {
+	doc := &Document{
+		SchemaVersion: SchemaVersion,
+		ScannedAt:     now().UTC().Truncate(time.Second),
+		Root:          root,
+		Options: Options{
+			ExtFilter:     config.ExtFilter,
+			Exclude:       config.ExcludePatterns,
+			IncludeHidden: config.IncludeHidden,
+			MaxDepth:      config.MaxDepth,
+			SortBy:        config.SortBy,
+			Order:         config.Order,
+			DirsFirst:     config.DirsFirst,
+			FilesFirst:    config.FilesFirst,
+			IgnoreCase:    config.IgnoreCase,
+			Collate:       config.Collate,
+			IntoArchives:  config.IntoArchives,
+			MinSize:       config.MinSize,
+			MaxSize:       config.MaxSize,
+			NewerThan:     config.NewerThan,
+			OlderThan:     config.OlderThan,
+			Types:         config.Types,
+			Empty:         config.Empty,
+			Where:         config.Where,
+			Find:          config.Find,
+			FindMode:      findMode(config),
+			Contains:      config.Contains,
+			FileLimit:     config.FileLimit,
+			MaxEntries:    config.MaxEntries,
+			MaxTotal:      config.MaxEntriesTotal,
+		},
+	}
+	if config.Timeout > 0 {
+		doc.Options.Timeout = config.Timeout.String()
+	}
+	doc.Counts.Directories, doc.Counts.Files = countTree(tree)
+	doc.Partial = tree.partial
+	if config.Stats {
+		doc.Summary = newSummary(tree, config.StatsTop)
+	}
+	doc.Tree = newEntry(tree)
+	return doc
+}
+
+// findMode returns the find mode recorded in the Document, empty without a
+// pattern.
+func findMode(config Config) string {
+	switch {
+	case config.Find == "":
+		return ""
+	case config.FindMode == "":
+		return "glob"
+	}
+	return config.FindMode
+}
+
+// countTree returns the number of directories and files below the root, as
+// in the text summary.
+func countTree(tree *Node) (dirs, files int) {
+	for _, child := range tree.Children {
+		if child.IsDir {
+			dirs++
+		} else {
+			files++
+		}
+		d, f := countTree(child)
+		dirs, files = dirs+d, files+f
+	}
+	return dirs, files
+}
+
+// newEntry converts node and its children to entries.
+func newEntry(node *Node) *Entry {
+	entry := &Entry{
+		Name:       node.Name,
+		Path:       node.Path,
+		Type:       nodeType(node),
+		LinkTarget: node.LinkTarget,
+	}
+	entry.XMLName.Local = entry.Type
+
+	if info := node.info; info != nil {
+		if !node.IsDir {
+			size := info.Size()
+			entry.Size = &size
+		}
+		if !info.ModTime().IsZero() {
+			entry.ModTime = info.ModTime().UTC().Format(time.RFC3339)
+		}
+		entry.Mode = permString(info.Mode(), node.IsDir)
+	}
+	if node.err != nil {
+		entry.Error = node.err.Error()
+	}
+	entry.LOC = node.loc
+	entry.Hits = node.hits
+	entry.Truncated = node.truncated
+
+	for _, child := range node.Children {
+		entry.Children = append(entry.Children, newEntry(child))
+	}
+	return entry
+}
@@ -0,0 +1,142 @@
+package printer
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TestDocument tests the versioned envelope of the structured formats.
+func TestDocument(t *testing.T) {
+	defer func(orig func() time.Time) { now = orig }(now)
+	now = func() time.Time { return time.Date(2025, 3, 4, 5, 6, 7, 0, time.UTC) }
+
+	modTime := time.Date(2025, 1, 2, 15, 4, 0, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"src/main.go": {Mode: 0644, Data: []byte("package main\n"), ModTime: modTime},
+		"src/link":    {Mode: fs.ModeSymlink | 0777, Data: []byte("main.go")},
+		"README.md":   {Mode: 0644, ModTime: modTime},
+	}
+	config := Config{SortBy: "name", Order: "asc", MaxDepth: -1, ExcludePatterns: []string{"*.tmp"}}
+
+	t.Run("JSON", func(t *testing.T) {
+		config := config
+		config.OutputFormat = "json"
+		output := captureOutput(func() { PrintFS(fsys, config) })
+
+		var doc Document
+		if err := json.Unmarshal([]byte(output), &doc); err != nil {
+			t.Fatalf("Output is not valid JSON: %v", err)
+		}
+		if doc.SchemaVersion != 1 || doc.Root != "." || !doc.ScannedAt.Equal(now()) {
+			t.Errorf("Unexpected metadata: %+v", doc)
+		}
+		if doc.Counts != (Counts{Directories: 1, Files: 3}) {
+			t.Errorf("Unexpected counts: %+v", doc.Counts)
+		}
+		if doc.Options.MaxDepth != -1 || len(doc.Options.Exclude) != 1 || doc.Options.SortBy != "name" {
+			t.Errorf("Unexpected options: %+v", doc.Options)
+		}
+
+		main := doc.Tree.Children[1].Children[1]
+		if main.Path != "src/main.go" || main.Type != "file" || main.Size == nil || *main.Size != 13 ||
+			main.ModTime != "2025-01-02T15:04:00Z" || main.Mode != "-rw-r--r--" {
+			t.Errorf("Unexpected entry: %+v", main)
+		}
+		link := doc.Tree.Children[1].Children[0]
+		if link.Type != "symlink" || link.LinkTarget != "main.go" {
+			t.Errorf("Unexpected entry: %+v", link)
+		}
+		if doc.Tree.Type != "dir" || doc.Tree.Size != nil {
+			t.Errorf("Unexpected root: %+v", doc.Tree)
+		}
+	})
+
+	t.Run("XML", func(t *testing.T) {
+		config := config
+		config.OutputFormat = "xml"
+		config.DirPath = "src"
+		output := captureOutput(func() { PrintFS(fsys, config) })
+
+		expected := xml.Header +
+			`<layout schema_version="1" scanned_at="2025-03-04T05:06:07Z" root="src">` + "\n" +
+			"  <options>\n" +
+			"    <exclude>*.tmp</exclude>\n" +
+			"    <hidden>false</hidden>\n" +
+			"    <max_depth>-1</max_depth>\n" +
+			"    <sort_by>name</sort_by>\n" +
+			"    <order>asc</order>\n" +
+			"    <into_archives>false</into_archives>\n" +
+			"  </options>\n" +
+			"  <counts>\n" +
+			"    <directories>0</directories>\n" +
+			"    <files>2</files>\n" +
+			"  </counts>\n" +
+			`  <dir name="src" path="." mode="dr-xr-xr-x">` + "\n" +
+			`    <symlink name="link" path="link" size="7" mode="lrwxrwxrwx" link_target="main.go"></symlink>` + "\n" +
+			`    <file name="main.go" path="main.go" size="13" mod_time="2025-01-02T15:04:00Z" mode="-rw-r--r--"></file>` + "\n" +
+			"  </dir>\n" +
+			"</layout>\n"
+		if output != expected {
+			t.Errorf("Unexpected output:\nGot:\n%s\nExpected:\n%s", output, expected)
+		}
+	})
+
+	t.Run("YAML", func(t *testing.T) {
+		config := config
+		config.OutputFormat = "yaml"
+		output := captureOutput(func() { PrintFS(fsys, config) })
+
+		var doc map[string]any
+		if err := yaml.Unmarshal([]byte(output), &doc); err != nil {
+			t.Fatalf("Output is not valid YAML: %v", err)
+		}
+		for _, key := range []string{"schema_version", "scanned_at", "root", "options", "counts", "tree"} {
+			if _, ok := doc[key]; !ok {
+				t.Errorf("Missing key %s in:\n%s", key, output)
+			}
+		}
+	})
+
+	t.Run("Legacy", func(t *testing.T) {
+		config := config
+		config.OutputFormat = "json"
+		config.LegacyOutput = true
+		output := captureOutput(func() { PrintFS(fsys, config) })
+
+		if !strings.HasPrefix(output, "{\n  \"name\": \".\",\n  \"path\": \".\",\n  \"is_dir\": true,") {
+			t.Errorf("Expected the legacy node shape, got:\n%s", output)
+		}
+	})
+}
+
+// TestSchemas tests that the embedded schemas parse and match the version.
+func TestSchemas(t *testing.T) {
+	var schema struct {
+		Properties struct {
+			SchemaVersion struct {
+				Const int `json:"const"`
+			} `json:"schema_version"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal([]byte(JSONSchema()), &schema); err != nil {
+		t.Fatalf("JSON Schema is not valid JSON: %v", err)
+	}
+	if schema.Properties.SchemaVersion.Const != SchemaVersion {
+		t.Errorf("JSON Schema is for version %d, expected %d", schema.Properties.SchemaVersion.Const, SchemaVersion)
+	}
+
+	var xsd any
+	if err := xml.Unmarshal([]byte(XMLSchema()), &xsd); err != nil {
+		t.Fatalf("XSD is not valid XML: %v", err)
+	}
+	if !strings.Contains(XMLSchema(), `name="schema_version" type="xs:integer" use="required" fixed="1"`) {
+		t.Errorf("XSD does not fix the schema version to %d", SchemaVersion)
+	}
+}
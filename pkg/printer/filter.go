@@ -0,0 +1,669 @@
+package printer
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Filter is a compiled --where expression such as
+//
+//	ext in (".go", ".mod") and size > 10KB and not path ~ "vendor/"
+//
+// Expressions combine comparisons with and, or, not and parentheses (&&, ||
+// and ! are accepted too). The fields are:
+//
+//	name, path, ext   strings; ext is the lowercase extension with its dot
+//	type              "dir", "file", "symlink" or "other"
+//	size              bytes, 0 for directories; literals may use K, M, G, ...
+//	depth             1 for children of the root
+//	mtime             modification time; literals are dates or ages as for --newer-than
+//	age               time since modification, e.g. age > 30d
+//	hidden, exec      booleans, usable alone: "not hidden"
+//
+// Operators are = (or ==), !=, <, <=, >, >=, in (...), not in (...), ~ and
+// !~ (regular expressions) and glob (path.Match patterns). Strings are
+// quoted with " or '. Fields, operators and literals are checked when the
+// expression is compiled, so Match never fails.
+type Filter struct {
+	source string
+	expr   filterExpr
+}
+
+// FilterEntry is the entry a Filter is evaluated against.
+type FilterEntry struct {
+	Path  string // as printed, relative to the root unless absolute paths were requested
+	Depth int    // 1 for children of the root
+	Info  fs.FileInfo
+	IsDir bool // also set for archives expanded as directories
+}
+
+// CompileFilter parses and checks an expression.
+func CompileFilter(source string) (*Filter, error) {
+	var expr filterExpr
+	tokens, err := lexFilter(source)
+	if err == nil {
+		p := &filterParser{tokens: tokens}
+		expr, err = p.parseOr()
+		if err == nil && p.peek().kind != tokenEOF {
+			err = p.errorf("unexpected %s", p.peek())
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter %q: %w", source, err)
+	}
+	return &Filter{source: source, expr: expr}, nil
+}
+
+// Match reports whether the entry matches. A nil Filter matches everything.
+func (f *Filter) Match(entry FilterEntry) bool {
+	if f == nil {
+		return true
+	}
+	return f.expr.eval(&entry)
+}
+
+// String returns the source of the expression.
+func (f *Filter) String() string {
+	return f.source
+}
+
+// filterExpr is a node of the expression tree.
+type filterExpr interface {
+	eval(entry *FilterEntry) bool
+}
+
+type andExpr struct{ left, right filterExpr }
+type orExpr struct{ left, right filterExpr }
+type notExpr struct{ expr filterExpr }
+type boolFieldExpr struct{ field string }
+
+func (e *andExpr) eval(entry *FilterEntry) bool { return e.left.eval(entry) && e.right.eval(entry) }
+func (e *orExpr) eval(entry *FilterEntry) bool  { return e.left.eval(entry) || e.right.eval(entry) }
+func (e *notExpr) eval(entry *FilterEntry) bool { return !e.expr.eval(entry) }
+
+func (e *boolFieldExpr) eval(entry *FilterEntry) bool {
+	return fieldValue(entry, e.field).(bool)
+}
+
+// suffixExpr matches string fields ending with suffix. It has no syntax of
+// its own and expresses --ext.
+type suffixExpr struct{ field, suffix string }
+
+func (e *suffixExpr) eval(entry *FilterEntry) bool {
+	return strings.HasSuffix(fieldValue(entry, e.field).(string), e.suffix)
+}
+
+// compareExpr compares a field with one value, or with a list for in.
+type compareExpr struct {
+	field  string
+	op     string
+	values []any // string, int64, time.Time, time.Duration or bool, by field kind
+	re     *regexp.Regexp
+}
+
+func (e *compareExpr) eval(entry *FilterEntry) bool {
+	value := fieldValue(entry, e.field)
+	switch e.op {
+	case "~":
+		return e.re.MatchString(value.(string))
+	case "!~":
+		return !e.re.MatchString(value.(string))
+	case "glob":
+		ok, _ := path.Match(e.values[0].(string), value.(string))
+		return ok
+	case "in", "not in":
+		found := false
+		for _, v := range e.values {
+			if compareValues(value, v) == 0 {
+				found = true
+				break
+			}
+		}
+		return found == (e.op == "in")
+	}
+
+	c := compareValues(value, e.values[0])
+	switch e.op {
+	case "=":
+		return c == 0
+	case "!=":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	}
+	return c >= 0
+}
+
+// compareValues orders two values of the same kind.
+func compareValues(a, b any) int {
+	switch a := a.(type) {
+	case string:
+		return strings.Compare(a, b.(string))
+	case int64:
+		return cmpOrdered(a, b.(int64))
+	case time.Duration:
+		return cmpOrdered(a, b.(time.Duration))
+	case time.Time:
+		return a.Compare(b.(time.Time))
+	case bool:
+		if a == b.(bool) {
+			return 0
+		}
+		return 1
+	}
+	return 0
+}
+
+func cmpOrdered[T int64 | time.Duration](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// filterFields are the fields of the expression language and their kinds.
+var filterFields = map[string]string{
+	"name":   "string",
+	"path":   "string",
+	"ext":    "string",
+	"type":   "string",
+	"size":   "size",
+	"depth":  "int",
+	"mtime":  "time",
+	"age":    "duration",
+	"hidden": "bool",
+	"exec":   "bool",
+}
+
+// fieldValue returns the value of a field for an entry.
+func fieldValue(entry *FilterEntry, field string) any {
+	info := entry.Info
+	name := path.Base(entry.Path)
+	if info != nil {
+		name = info.Name()
+	}
+	switch field {
+	case "name":
+		return name
+	case "path":
+		return entry.Path
+	case "ext":
+		if entry.IsDir {
+			return ""
+		}
+		return strings.ToLower(path.Ext(name))
+	case "type":
+		switch {
+		case entry.IsDir:
+			return "dir"
+		case info == nil || info.Mode().IsRegular():
+			return "file"
+		case info.Mode()&fs.ModeSymlink != 0:
+			return "symlink"
+		}
+		return "other"
+	case "size":
+		if entry.IsDir || info == nil {
+			return int64(0)
+		}
+		return info.Size()
+	case "depth":
+		return int64(entry.Depth)
+	case "mtime":
+		if info == nil {
+			return time.Time{}
+		}
+		return info.ModTime()
+	case "age":
+		if info == nil {
+			return time.Duration(0)
+		}
+		return now().Sub(info.ModTime())
+	case "hidden":
+		return strings.HasPrefix(name, ".")
+	case "exec":
+		return !entry.IsDir && info != nil && info.Mode().IsRegular() && isExecutable(info)
+	}
+	panic("unknown filter field " + field)
+}
+
+// Tokens of the expression language.
+const (
+	tokenEOF = iota
+	tokenIdent
+	tokenString
+	tokenLiteral // unquoted number, size, age or date
+	tokenOp
+	tokenPunct
+)
+
+type filterToken struct {
+	kind int
+	text string
+	pos  int
+}
+
+func (t filterToken) String() string {
+	if t.kind == tokenEOF {
+		return "end of expression"
+	}
+	return strconv.Quote(t.text)
+}
+
+// lexFilter splits an expression into tokens. Strings are unquoted.
+func lexFilter(source string) ([]filterToken, error) {
+	var tokens []filterToken
+	for i := 0; i < len(source); {
+		c := rune(source[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '"' || c == '\'':
+			end := i + 1
+			for end < len(source) && source[end] != byte(c) {
+				if c == '"' && source[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(source) {
+				return nil, fmt.Errorf("at column %d: unterminated string", i+1)
+			}
+			text := source[i+1 : end]
+			if c == '"' {
+				unquoted, err := strconv.Unquote(source[i : end+1])
+				if err != nil {
+					return nil, fmt.Errorf("at column %d: invalid string %s", i+1, source[i:end+1])
+				}
+				text = unquoted
+			}
+			tokens = append(tokens, filterToken{tokenString, text, i + 1})
+			i = end + 1
+		case c == '(' || c == ')' || c == ',':
+			tokens = append(tokens, filterToken{tokenPunct, string(c), i + 1})
+			i++
+		case strings.ContainsRune("=!<>~&|", c):
+			op := string(c)
+			for _, two := range []string{"==", "!=", "<=", ">=", "!~", "&&", "||"} {
+				if strings.HasPrefix(source[i:], two) {
+					op = two
+				}
+			}
+			if op == "&" || op == "|" {
+				return nil, fmt.Errorf("at column %d: unexpected %q", i+1, op)
+			}
+			tokens = append(tokens, filterToken{tokenOp, op, i + 1})
+			i += len(op)
+		case unicode.IsDigit(c) || c == '.' || c == '-':
+			end := i
+			for end < len(source) && (isWordByte(source[end]) || strings.IndexByte(".-:", source[end]) >= 0) {
+				end++
+			}
+			tokens = append(tokens, filterToken{tokenLiteral, source[i:end], i + 1})
+			i = end
+		case isWordByte(source[i]):
+			end := i
+			for end < len(source) && isWordByte(source[end]) {
+				end++
+			}
+			tokens = append(tokens, filterToken{tokenIdent, source[i:end], i + 1})
+			i = end
+		default:
+			return nil, fmt.Errorf("at column %d: unexpected %q", i+1, c)
+		}
+	}
+	return append(tokens, filterToken{kind: tokenEOF, pos: len(source) + 1}), nil
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// filterParser is a recursive descent parser over the tokens:
+//
+//	or      = and { ("or" | "||") and }
+//	and     = unary { ("and" | "&&") unary }
+//	unary   = ("not" | "!") unary | "(" or ")" | field [ op value | ["not"] "in" "(" value { "," value } ")" ]
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+}
+
+func (p *filterParser) peek() filterToken { return p.tokens[p.pos] }
+
+// back returns t, the last token read, to the input.
+func (p *filterParser) back(t filterToken) {
+	if t.kind != tokenEOF {
+		p.pos--
+	}
+}
+
+func (p *filterParser) next() filterToken {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// accept consumes the next token if it is one of words, case-insensitively
+// for keywords.
+func (p *filterParser) accept(words ...string) bool {
+	t := p.peek()
+	for _, w := range words {
+		if (t.kind == tokenIdent || t.kind == tokenOp || t.kind == tokenPunct) && strings.EqualFold(t.text, w) {
+			p.pos++
+			return true
+		}
+	}
+	return false
+}
+
+func (p *filterParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("at column %d: %s", p.peek().pos, fmt.Sprintf(format, args...))
+}
+
+func (p *filterParser) parseOr() (filterExpr, error) {
+	left, err := p.parseAnd()
+	for err == nil && p.accept("or", "||") {
+		var right filterExpr
+		if right, err = p.parseAnd(); err == nil {
+			left = &orExpr{left, right}
+		}
+	}
+	return left, err
+}
+
+func (p *filterParser) parseAnd() (filterExpr, error) {
+	left, err := p.parseUnary()
+	for err == nil && p.accept("and", "&&") {
+		var right filterExpr
+		if right, err = p.parseUnary(); err == nil {
+			left = &andExpr{left, right}
+		}
+	}
+	return left, err
+}
+
+func (p *filterParser) parseUnary() (filterExpr, error) {
+	if p.accept("not", "!") {
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notExpr{expr}, nil
+	}
+	if p.accept("(") {
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, p.errorf("expected \")\", found %s", p.peek())
+		}
+		return expr, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *filterParser) parseComparison() (filterExpr, error) {
+	t := p.next()
+	if t.kind != tokenIdent {
+		p.back(t)
+		return nil, p.errorf("expected a field, found %s", t)
+	}
+	field := strings.ToLower(t.text)
+	kind, ok := filterFields[field]
+	if !ok {
+		p.back(t)
+		return nil, p.errorf("unknown field %q", t.text)
+	}
+
+	expr := &compareExpr{field: field}
+	switch {
+	case p.accept("in"):
+		expr.op = "in"
+	case p.peek().kind == tokenIdent && strings.EqualFold(p.peek().text, "not") &&
+		p.tokens[p.pos+1].kind == tokenIdent && strings.EqualFold(p.tokens[p.pos+1].text, "in"):
+		p.pos += 2
+		expr.op = "not in"
+	case p.accept("glob"):
+		expr.op = "glob"
+	case p.peek().kind == tokenOp && strings.Contains(" = == != < <= > >= ~ !~ ", " "+p.peek().text+" "):
+		expr.op = strings.Replace(p.next().text, "==", "=", 1)
+	default:
+		if kind == "bool" {
+			return &boolFieldExpr{field}, nil
+		}
+		return nil, p.errorf("expected an operator after %s, found %s", field, p.peek())
+	}
+
+	opPos := p.tokens[p.pos-1].pos
+	switch expr.op {
+	case "<", "<=", ">", ">=":
+		if kind == "string" || kind == "bool" {
+			return nil, fmt.Errorf("at column %d: %s cannot be compared with %s", opPos, field, expr.op)
+		}
+	case "~", "!~", "glob":
+		if kind != "string" {
+			return nil, fmt.Errorf("at column %d: %s needs a string field, not %s", opPos, expr.op, field)
+		}
+	}
+
+	if expr.op == "in" || expr.op == "not in" {
+		if !p.accept("(") {
+			return nil, p.errorf("expected \"(\" after in")
+		}
+		for {
+			value, err := p.parseValue(field, kind)
+			if err != nil {
+				return nil, err
+			}
+			expr.values = append(expr.values, value)
+			if p.accept(")") {
+				break
+			}
+			if !p.accept(",") {
+				return nil, p.errorf("expected \",\" or \")\", found %s", p.peek())
+			}
+		}
+		return expr, nil
+	}
+
+	value, err := p.parseValue(field, kind)
+	if err != nil {
+		return nil, err
+	}
+	expr.values = []any{value}
+	switch expr.op {
+	case "~", "!~":
+		if expr.re, err = regexp.Compile(value.(string)); err != nil {
+			return nil, fmt.Errorf("at column %d: %w", opPos, err)
+		}
+	case "glob":
+		if _, err := path.Match(value.(string), ""); err != nil {
+			return nil, fmt.Errorf("at column %d: bad glob pattern %q", opPos, value)
+		}
+	}
+	return expr, nil
+}
+
+// parseValue reads a literal and converts it to the kind of the field.
+func (p *filterParser) parseValue(field, kind string) (any, error) {
+	t := p.next()
+	if t.kind != tokenString && t.kind != tokenLiteral && t.kind != tokenIdent {
+		p.back(t)
+		return nil, p.errorf("expected a value for %s, found %s", field, t)
+	}
+	fail := func(expected string) (any, error) {
+		return nil, fmt.Errorf("at column %d: %s is not %s", t.pos, t, expected)
+	}
+
+	switch kind {
+	case "string":
+		if t.kind != tokenString {
+			return fail("a quoted string")
+		}
+		if field == "type" && !strings.Contains(" dir file symlink other ", " "+t.text+" ") {
+			return fail("a type (dir, file, symlink, other)")
+		}
+		if field == "ext" {
+			return strings.ToLower(t.text), nil
+		}
+		return t.text, nil
+	case "size":
+		size, err := parseSize(t.text)
+		if err != nil {
+			return fail("a size")
+		}
+		return size, nil
+	case "int":
+		n, err := strconv.ParseInt(t.text, 10, 64)
+		if err != nil {
+			return fail("a number")
+		}
+		return n, nil
+	case "time":
+		bound, err := parseTimeBound(t.text)
+		if err != nil {
+			return fail("a date or age")
+		}
+		return bound, nil
+	case "duration":
+		age, ok := parseAge(t.text)
+		if !ok {
+			return fail("an age such as 12h or 30d")
+		}
+		return age, nil
+	}
+	// bool
+	switch strings.ToLower(t.text) {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	return fail("true or false")
+}
+
+// walkFilters are the compiled filters of a walk.
+type walkFilters struct {
+	// entries holds the hidden, exclude and ext options. Entries that do not
+	// match are skipped, and directories that do not match are not read.
+	entries *Filter
+
+	// where holds Config.Where and Config.Filter. Like the predicates, they
+	// select files; directories are kept when they contain a match or match
+	// themselves.
+	where []*Filter
+	preds *predicates
+
+	// find holds the pattern of pr find, which selects like where and
+	// records the matched parts of names for highlighting.
+	find *finder
+
+	// contents holds Config.Contains. Files are searched after the other
+	// filters pass, and directories are kept only when they contain a hit.
+	contents *contentScanner
+}
+
+// newWalkFilters compiles the filters of config.
+func newWalkFilters(config Config) (*walkFilters, error) {
+	filters := &walkFilters{entries: optionsFilter(config)}
+	if config.Where != "" {
+		where, err := CompileFilter(config.Where)
+		if err != nil {
+			return nil, fmt.Errorf("--where: %w", err)
+		}
+		filters.where = append(filters.where, where)
+	}
+	if config.Filter != nil {
+		filters.where = append(filters.where, config.Filter)
+	}
+	var err error
+	if filters.find, err = newFinder(config.Find, config.FindMode); err != nil {
+		return nil, err
+	}
+	if filters.contents, err = newContentScanner(config); err != nil {
+		return nil, err
+	}
+	filters.preds, err = newPredicates(config)
+	return filters, err
+}
+
+// optionsFilter expresses the hidden, exclude and ext options as a Filter,
+// or returns nil when they let everything through. Invalid exclude patterns
+// are reported and ignored. The expression is built directly rather than
+// parsed, so any option value is accepted.
+func optionsFilter(config Config) *Filter {
+	var terms []string
+	var expr filterExpr
+	add := func(term string, e filterExpr) {
+		terms = append(terms, term)
+		if expr == nil {
+			expr = e
+		} else {
+			expr = &andExpr{expr, e}
+		}
+	}
+
+	if !config.IncludeHidden {
+		add("not hidden", &notExpr{&boolFieldExpr{"hidden"}})
+	}
+	for _, pattern := range config.ExcludePatterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			fmt.Printf("Invalid exclude pattern: %s\n", pattern)
+			continue
+		}
+		add("not name glob "+strconv.Quote(pattern),
+			&notExpr{&compareExpr{field: "name", op: "glob", values: []any{pattern}}})
+	}
+	if config.ExtFilter != "" {
+		add(fmt.Sprintf("(type = \"dir\" or name ends with %s)", strconv.Quote(config.ExtFilter)),
+			&orExpr{&compareExpr{field: "type", op: "=", values: []any{"dir"}}, &suffixExpr{"name", config.ExtFilter}})
+	}
+	if expr == nil {
+		return nil
+	}
+	return &Filter{source: strings.Join(terms, " and "), expr: expr}
+}
+
+// matchFile reports whether an entry that is not a directory is shown.
+func (w *walkFilters) matchFile(entry FilterEntry) bool {
+	for _, filter := range w.where {
+		if !filter.Match(entry) {
+			return false
+		}
+	}
+	return w.find.matches(entry) && w.preds.matchFile(entry.Info)
+}
+
+// keepDir reports whether a walked directory is shown.
+func (w *walkFilters) keepDir(ctx context.Context, node *Node, entry FilterEntry) bool {
+	if len(node.Children) > 0 {
+		return true
+	}
+	if w.contents != nil {
+		return false
+	}
+	for _, filter := range w.where {
+		if !filter.Match(entry) {
+			return false
+		}
+	}
+	return w.find.matches(entry) && w.preds.keepDir(ctx, node)
+}
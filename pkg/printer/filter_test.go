@@ -0,0 +1,178 @@
+package printer
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// filterFS returns a small project with vendored code and hidden files.
+func filterFS() fstest.MapFS {
+	day := func(d int) time.Time { return time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, -d) }
+	return fstest.MapFS{
+		"go.mod":               {Data: make([]byte, 100), ModTime: day(300)},
+		"main.go":              {Data: make([]byte, 12000), ModTime: day(1)},
+		"README.md":            {Data: make([]byte, 3000), ModTime: day(40)},
+		".env":                 {Data: make([]byte, 10), ModTime: day(5)},
+		"bin/tool":             {Data: make([]byte, 50000), Mode: 0755, ModTime: day(2)},
+		"bin/tool.link":        {Data: []byte("tool"), Mode: fs.ModeSymlink | 0777},
+		"vendor/lib/lib.go":    {Data: make([]byte, 20000), ModTime: day(200)},
+		"internal/a/b/deep.GO": {Data: make([]byte, 500), ModTime: day(10)},
+		"docs":                 {Mode: fs.ModeDir | 0755},
+	}
+}
+
+// listTree returns the paths of the tree, with a slash after directories.
+func listTree(tree *Node) string {
+	var paths []string
+	var visit func(node *Node)
+	visit = func(node *Node) {
+		for _, child := range node.Children {
+			if child.IsDir {
+				paths = append(paths, child.Path+"/")
+			} else {
+				paths = append(paths, child.Path)
+			}
+			visit(child)
+		}
+	}
+	visit(tree)
+	return strings.Join(paths, " ")
+}
+
+// TestWhere tests expressions evaluated during the walk.
+func TestWhere(t *testing.T) {
+	defer func(orig func() time.Time) { now = orig }(now)
+	now = func() time.Time { return time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		where    string
+		expected string
+	}{
+		{`ext in (".go", ".mod") and size > 10KB and not path ~ "vendor/"`, "main.go"},
+		{`ext = ".go"`, "internal/ internal/a/ internal/a/b/ internal/a/b/deep.GO main.go vendor/ vendor/lib/ vendor/lib/lib.go"},
+		{`ext == ".go" && !(path ~ "^vendor" || depth > 2)`, "main.go"},
+		{`name glob "*.md" or exec`, "README.md bin/ bin/tool"},
+		{`type not in ("file", "dir")`, "bin/ bin/tool.link"},
+		{`type = "dir" and depth <= 1`, "bin/ docs/ internal/ vendor/"},
+		{`hidden`, ".env"},
+		{`hidden = false and age > 100d and type = "file"`, "go.mod vendor/ vendor/lib/ vendor/lib/lib.go"},
+		{`mtime >= "2025-05-25" and size < 1K`, ".env"},
+		{`SIZE>=50k AND NOT EXEC`, ""},
+		{`size >= 12000 and size <= 20000`, "main.go vendor/ vendor/lib/ vendor/lib/lib.go"},
+	}
+	for _, test := range tests {
+		config := Config{SortBy: "name", Order: "asc", MaxDepth: -1, IncludeHidden: true, Where: test.where}
+		tree, err := BuildTree(filterFS(), config)
+		if err != nil {
+			t.Errorf("%s: BuildTree failed: %v", test.where, err)
+			continue
+		}
+		if got := listTree(tree); got != test.expected {
+			t.Errorf("%s:\ngot      %q\nexpected %q", test.where, got, test.expected)
+		}
+	}
+}
+
+// TestCompileFilterErrors tests that mistakes are found before the walk.
+func TestCompileFilterErrors(t *testing.T) {
+	tests := map[string]string{
+		`size > `:               "at column 8: expected a value for size, found end of expression",
+		`colour = "red"`:        `at column 1: unknown field "colour"`,
+		`name < "x"`:            "at column 6: name cannot be compared with <",
+		`size ~ "1"`:            "at column 6: ~ needs a string field, not size",
+		`size > big`:            `at column 8: "big" is not a size`,
+		`ext = go`:              `at column 7: "go" is not a quoted string`,
+		`type = "folder"`:       `at column 8: "folder" is not a type (dir, file, symlink, other)`,
+		`age > "2025-01-01"`:    `at column 7: "2025-01-01" is not an age such as 12h or 30d`,
+		`name ~ "("`:            "at column 6: error parsing regexp",
+		`name glob "["`:         `at column 6: bad glob pattern "["`,
+		`(hidden`:               `at column 8: expected ")", found end of expression`,
+		`hidden exec`:           `at column 8: unexpected "exec"`,
+		`name = "unterminated`:  "at column 8: unterminated string",
+		`ext in (".go" ".mod")`: `at column 15: expected "," or ")", found ".mod"`,
+		`hidden & exec`:         `at column 8: unexpected "&"`,
+		`depth = 1.5`:           `at column 9: "1.5" is not a number`,
+	}
+	for source, expected := range tests {
+		_, err := CompileFilter(source)
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("CompileFilter(%q) = %v, expected an error containing %q", source, err, expected)
+		}
+	}
+}
+
+// TestFilterLibrary tests a compiled Filter set on the Config, combined with
+// the hidden, exclude and ext options that the walk expresses as a Filter.
+func TestFilterLibrary(t *testing.T) {
+	filter, err := CompileFilter(`size > 1K`)
+	if err != nil {
+		t.Fatalf("CompileFilter failed: %v", err)
+	}
+	if filter.String() != `size > 1K` {
+		t.Errorf("Unexpected source %q", filter.String())
+	}
+
+	config := Config{SortBy: "name", Order: "asc", MaxDepth: -1, ExcludePatterns: []string{"vendor", "[", "bin"}, ExtFilter: ".go", Filter: filter}
+	var tree *Node
+	output := captureOutput(func() { tree, err = BuildTree(filterFS(), config) })
+	if err != nil {
+		t.Fatalf("BuildTree failed: %v", err)
+	}
+	if output != "Invalid exclude pattern: [\n" {
+		t.Errorf("Expected the invalid pattern to be reported once, got %q", output)
+	}
+	if got, expected := listTree(tree), "main.go"; got != expected {
+		t.Errorf("got %q, expected %q", got, expected)
+	}
+
+	// Without a Where filter, directories are kept even when empty
+	config.Filter = nil
+	captureOutput(func() { tree, _ = BuildTree(filterFS(), config) })
+	if got, expected := listTree(tree), "docs/ internal/ internal/a/ internal/a/b/ main.go"; got != expected {
+		t.Errorf("got %q, expected %q", got, expected)
+	}
+
+	// Filters can be evaluated outside a walk
+	info, _ := fs.Stat(fstest.MapFS{"big": {Data: make([]byte, 2000)}}, "big")
+	if !filter.Match(FilterEntry{Path: "big", Depth: 1, Info: info}) {
+		t.Error("Expected a 2000 byte file to match")
+	}
+	if !(*Filter)(nil).Match(FilterEntry{Path: "any"}) {
+		t.Error("Expected a nil Filter to match")
+	}
+}
+
+// TestOptionsFilter tests that any option value is accepted, including ones
+// that are not valid in a filter expression.
+func TestOptionsFilter(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.go":    {},
+		"b.c++":   {},
+		"c.txt":   {},
+		"d/e.c++": {},
+	}
+	for _, test := range []struct{ ext, expected string }{
+		{".c++", "b.c++ d/ d/e.c++"},
+		{"\xff", "d/"},
+	} {
+		tree, err := BuildTree(fsys, Config{SortBy: "name", Order: "asc", MaxDepth: -1, ExtFilter: test.ext})
+		if err != nil {
+			t.Errorf("%q: BuildTree failed: %v", test.ext, err)
+			continue
+		}
+		if got := listTree(tree); got != test.expected {
+			t.Errorf("%q: got %q, expected %q", test.ext, got, test.expected)
+		}
+	}
+
+	filter := optionsFilter(Config{ExtFilter: ".go", ExcludePatterns: []string{"vendor"}})
+	if expected := `not hidden and not name glob "vendor" and (type = "dir" or name ends with ".go")`; filter.String() != expected {
+		t.Errorf("got %q, expected %q", filter.String(), expected)
+	}
+	if optionsFilter(Config{IncludeHidden: true}) != nil {
+		t.Error("Expected no filter without options")
+	}
+}
@@ -0,0 +1,228 @@
+package printer
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// findModes are the pattern syntaxes of pr find.
+var findModes = []string{"glob", "regex", "fuzzy"}
+
+// finder matches the pattern of pr find against entry names, or against
+// paths relative to the root when the pattern contains a slash. Matching is
+// case-insensitive unless the pattern has an upper-case letter.
+type finder struct {
+	re    *regexp.Regexp // glob and regex modes
+	fuzzy []rune         // fuzzy mode, lower-cased when folding
+	paths bool
+	fold  bool
+}
+
+// newFinder compiles pattern in the given mode, returning nil for an empty
+// pattern.
+func newFinder(pattern, mode string) (*finder, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+
+	f := &finder{paths: strings.Contains(pattern, "/"), fold: !hasUpper(pattern)}
+	expr := pattern
+	switch mode {
+	case "", "glob":
+		var err error
+		if expr, err = globRegexp(pattern, f.paths); err != nil {
+			return nil, err
+		}
+	case "regex":
+	case "fuzzy":
+		if f.fold {
+			pattern = strings.ToLower(pattern)
+		}
+		f.fuzzy = []rune(pattern)
+		return f, nil
+	default:
+		return nil, fmt.Errorf("--mode: unknown find mode %q (available: %s)", mode, strings.Join(findModes, ", "))
+	}
+
+	if f.fold {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	f.re = re
+	return f, nil
+}
+
+// hasUpper reports whether s has an upper-case letter.
+func hasUpper(s string) bool {
+	return strings.IndexFunc(s, unicode.IsUpper) >= 0
+}
+
+// globRegexp translates a glob into a regular expression. A glob without
+// wildcards matches anywhere in the name. Other globs match the whole name,
+// or with paths the trailing segments of the path, where ** matches any
+// number of segments.
+func globRegexp(glob string, paths bool) (string, error) {
+	if !strings.ContainsAny(glob, `*?[\`) {
+		return regexp.QuoteMeta(glob), nil
+	}
+
+	var sb strings.Builder
+	if paths {
+		sb.WriteString("(?:^|/)")
+	} else {
+		sb.WriteString("^")
+	}
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			switch {
+			case strings.HasPrefix(glob[i:], "**/"):
+				sb.WriteString("(?:.*/)?")
+				i += 2
+			case strings.HasPrefix(glob[i:], "**"):
+				sb.WriteString(".*")
+				i++
+			default:
+				sb.WriteString("[^/]*")
+			}
+		case '?':
+			sb.WriteString("[^/]")
+		case '\\':
+			if i+1 == len(glob) {
+				return "", fmt.Errorf("bad glob pattern %q: trailing backslash", glob)
+			}
+			i++
+			sb.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				return "", fmt.Errorf("bad glob pattern %q: unterminated [", glob)
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + class + "]")
+			i += end + 1
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+	return sb.String(), nil
+}
+
+// match reports whether the entry matches, with the matched byte ranges of
+// its name. Parts of a path match that fall in the parent directories are
+// not returned.
+func (f *finder) match(entry FilterEntry) ([][2]int, bool) {
+	name := path.Base(entry.Path)
+	subject := name
+	if f.paths {
+		subject = entry.Path
+	}
+
+	var ranges [][2]int
+	if f.fuzzy != nil {
+		var ok bool
+		if ranges, ok = f.fuzzyMatch(subject); !ok {
+			return nil, false
+		}
+	} else {
+		found := f.re.FindAllStringIndex(subject, -1)
+		if found == nil {
+			return nil, false
+		}
+		for _, r := range found {
+			ranges = append(ranges, [2]int{r[0], r[1]})
+		}
+	}
+
+	// Move the ranges from the subject onto the name at its end
+	offset := len(subject) - len(name)
+	var clipped [][2]int
+	for _, r := range ranges {
+		start, end := max(r[0]-offset, 0), r[1]-offset
+		if end > start {
+			clipped = append(clipped, [2]int{start, end})
+		}
+	}
+	return clipped, true
+}
+
+// fuzzyMatch finds the pattern runes in order in s, returning the range of
+// each one.
+func (f *finder) fuzzyMatch(s string) ([][2]int, bool) {
+	var ranges [][2]int
+	next := 0
+	for i, c := range s {
+		if next == len(f.fuzzy) {
+			break
+		}
+		r := c
+		if f.fold {
+			r = unicode.ToLower(c)
+		}
+		if r != f.fuzzy[next] {
+			continue
+		}
+		end := i + utf8.RuneLen(c)
+		if n := len(ranges); n > 0 && ranges[n-1][1] == i {
+			ranges[n-1][1] = end
+		} else {
+			ranges = append(ranges, [2]int{i, end})
+		}
+		next++
+	}
+	return ranges, next == len(f.fuzzy)
+}
+
+// matches reports whether the entry is shown, which it always is without a
+// pattern.
+func (f *finder) matches(entry FilterEntry) bool {
+	if f == nil {
+		return true
+	}
+	_, ok := f.match(entry)
+	return ok
+}
+
+// highlights returns the matched ranges of the entry name, nil without a
+// pattern.
+func (f *finder) highlights(entry FilterEntry) [][2]int {
+	if f == nil {
+		return nil
+	}
+	ranges, _ := f.match(entry)
+	return ranges
+}
+
+// highlight colors the matched ranges of the name at the end of label with
+// match, and everything else with base.
+func highlight(label, name string, ranges [][2]int, base, match func(a ...interface{}) string) string {
+	if len(ranges) == 0 {
+		return base(label)
+	}
+	offset := len(label) - len(name)
+	var sb strings.Builder
+	last := 0
+	for _, r := range ranges {
+		start, end := r[0]+offset, r[1]+offset
+		if start > last {
+			sb.WriteString(base(label[last:start]))
+		}
+		sb.WriteString(match(label[start:end]))
+		last = end
+	}
+	if last < len(label) {
+		sb.WriteString(base(label[last:]))
+	}
+	return sb.String()
+}
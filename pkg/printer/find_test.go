@@ -0,0 +1,106 @@
+package printer
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestFind tests the glob, regex and fuzzy patterns of pr find.
+func TestFind(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		mode     string
+		config   Config
+		expected string
+	}{
+		{"lib", "", Config{}, "vendor/ vendor/lib/ vendor/lib/lib.go"},
+		{"*.go", "glob", Config{}, "internal/ internal/a/ internal/a/b/ internal/a/b/deep.GO main.go vendor/ vendor/lib/ vendor/lib/lib.go"},
+		{"*.Go", "glob", Config{}, ""},
+		{"*.GO", "glob", Config{}, "internal/ internal/a/ internal/a/b/ internal/a/b/deep.GO"},
+		{"*.go", "glob", Config{ExcludePatterns: []string{"vendor"}}, "internal/ internal/a/ internal/a/b/ internal/a/b/deep.GO main.go"},
+		{"*.go", "glob", Config{MaxDepth: 2}, "main.go"},
+		{"internal/**/*.go", "glob", Config{}, "internal/ internal/a/ internal/a/b/ internal/a/b/deep.GO"},
+		{"a/?", "glob", Config{}, "internal/ internal/a/ internal/a/b/"},
+		{"[!.]env", "glob", Config{}, ""},
+		{"*env", "glob", Config{IncludeHidden: true}, ".env"},
+		{`^(go|main)\.`, "regex", Config{}, "go.mod main.go"},
+		{`b/.*\.go$`, "regex", Config{}, "internal/ internal/a/ internal/a/b/ internal/a/b/deep.GO vendor/ vendor/lib/ vendor/lib/lib.go"},
+		{"tk", "fuzzy", Config{}, "bin/ bin/tool.link"},
+		{"bin/tl", "fuzzy", Config{}, "bin/ bin/tool bin/tool.link"},
+		{"doc", "fuzzy", Config{}, "docs/"},
+	}
+	for _, test := range tests {
+		config := test.config
+		config.SortBy, config.Order, config.Find, config.FindMode = "name", "asc", test.pattern, test.mode
+		if config.MaxDepth == 0 {
+			config.MaxDepth = -1
+		}
+		tree, err := BuildTree(filterFS(), config)
+		if err != nil {
+			t.Errorf("%s (%s): BuildTree failed: %v", test.pattern, test.mode, err)
+			continue
+		}
+		if got := listTree(tree); got != test.expected {
+			t.Errorf("%s (%s):\ngot      %q\nexpected %q", test.pattern, test.mode, got, test.expected)
+		}
+	}
+}
+
+// TestFindHighlights tests the matched ranges recorded on the nodes and
+// their rendering in the text output.
+func TestFindHighlights(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		mode     string
+		expected string
+	}{
+		{"ma", "glob", "[ma]in.go"},
+		{"*.go", "glob", "[lib.go]"},
+		{"i|g", "regex", "l[i]b.[g]o"},
+		{"v.*/lib.go", "regex", "[lib.go]"},
+		{"mngo", "fuzzy", "[m]ai[n].[go]"},
+		{"bin/tk", "fuzzy", "[t]ool.lin[k]"},
+	}
+	for _, test := range tests {
+		config := Config{SortBy: "name", Order: "asc", MaxDepth: -1, Find: test.pattern, FindMode: test.mode}
+		tree, err := BuildTree(filterFS(), config)
+		if err != nil {
+			t.Fatalf("%s: BuildTree failed: %v", test.pattern, err)
+		}
+		var last *Node
+		for node := tree; len(node.Children) > 0; node = node.Children[len(node.Children)-1] {
+			last = node.Children[len(node.Children)-1]
+		}
+		brackets := func(a ...interface{}) string { return "[" + fmt.Sprint(a...) + "]" }
+		if got := highlight(last.Name, last.Name, last.matches, fmt.Sprint, brackets); got != test.expected {
+			t.Errorf("%s (%s): got %q, expected %q", test.pattern, test.mode, got, test.expected)
+		}
+	}
+
+	// With --full-path, the parent directories are not highlighted
+	colors := *plainPalette
+	colors.match = func(a ...interface{}) string { return "<" + fmt.Sprint(a...) + ">" }
+	tree, _ := BuildTree(filterFS(), Config{SortBy: "name", Order: "asc", MaxDepth: -1, Find: "b/*.GO"})
+	output := getTreeOutput(tree, textOptions{colors: &colors, style: treeStyles["ascii"], fullPath: true})
+	if !strings.Contains(output, "internal/a/b/<deep.GO>\n") {
+		t.Errorf("Expected the matched path to be highlighted, got:\n%s", output)
+	}
+}
+
+// TestFindErrors tests that invalid patterns and modes are reported.
+func TestFindErrors(t *testing.T) {
+	for _, test := range []struct {
+		pattern, mode, expected string
+	}{
+		{"[abc", "glob", `bad glob pattern "[abc": unterminated [`},
+		{`a\`, "glob", "trailing backslash"},
+		{"(", "regex", `invalid pattern "(": error parsing regexp`},
+		{"x", "exact", `--mode: unknown find mode "exact"`},
+	} {
+		_, err := BuildTree(filterFS(), Config{MaxDepth: -1, Find: test.pattern, FindMode: test.mode})
+		if err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("%s (%s): got %v, expected an error containing %q", test.pattern, test.mode, err, test.expected)
+		}
+	}
+}
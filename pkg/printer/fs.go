@@ -0,0 +1,64 @@
+package printer
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// FS is a file system that can also report symbolic links without following
+// them. The walker accepts any fs.FS; when it also implements FS, entries are
+// read with Lstat and symlinks are shown with their targets.
+type FS interface {
+	fs.FS
+	Lstat(name string) (fs.FileInfo, error)
+	ReadLink(name string) (string, error)
+}
+
+// DirFS returns an FS for the directory tree rooted at dir on disk.
+func DirFS(dir string) FS {
+	return osFS{FS: os.DirFS(dir), dir: dir}
+}
+
+// osFS adds Lstat and ReadLink to os.DirFS.
+type osFS struct {
+	fs.FS
+	dir string
+}
+
+// ReadDir implements fs.ReadDirFS so listings avoid an extra open.
+func (o osFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(o.FS, name)
+}
+
+func (o osFS) Lstat(name string) (fs.FileInfo, error) {
+	full, err := o.join("lstat", name)
+	if err != nil {
+		return nil, err
+	}
+	return os.Lstat(full)
+}
+
+func (o osFS) ReadLink(name string) (string, error) {
+	full, err := o.join("readlink", name)
+	if err != nil {
+		return "", err
+	}
+	return os.Readlink(full)
+}
+
+// join maps an fs.FS path to a path on disk.
+func (o osFS) join(op, name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	return filepath.Join(o.dir, filepath.FromSlash(name)), nil
+}
+
+// readLink returns the target of the symlink at name, if fsys can tell.
+func readLink(fsys fs.FS, name string) (string, error) {
+	if lfs, ok := fsys.(FS); ok {
+		return lfs.ReadLink(name)
+	}
+	return "", fs.ErrInvalid
+}
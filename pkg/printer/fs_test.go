@@ -0,0 +1,125 @@
+package printer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// testProjectFS returns the same layout as createTestProjectStructure as an
+// in-memory file system.
+func testProjectFS() fstest.MapFS {
+	return fstest.MapFS{
+		"cmd/main.go":                 {},
+		"internal/utils/utils.go":     {},
+		"pkg/printer/printer.go":      {},
+		"pkg/printer/printer_test.go": {},
+		"go.mod":                      {},
+		".git/HEAD":                   {},
+		"bin/pr":                      {Mode: 0755},
+	}
+}
+
+// TestPrintFS tests printing an arbitrary fs.FS without touching the disk.
+func TestPrintFS(t *testing.T) {
+	config := Config{NoColor: true, OutputFormat: "text", SortBy: "name", Order: "asc", MaxDepth: -1}
+
+	t.Run("Root", func(t *testing.T) {
+		output := captureOutput(func() { PrintFS(testProjectFS(), config) })
+
+		expected := "./\n" +
+			"├── bin/\n" +
+			"│   └── pr\n" +
+			"├── cmd/\n" +
+			"│   └── main.go\n" +
+			"├── go.mod\n" +
+			"├── internal/\n" +
+			"│   └── utils/\n" +
+			"│       └── utils.go\n" +
+			"└── pkg/\n" +
+			"    └── printer/\n" +
+			"        ├── printer.go\n" +
+			"        └── printer_test.go\n" +
+			"\n6 directories, 6 files\n"
+		if output != expected {
+			t.Errorf("Unexpected output:\nGot:\n%s\nExpected:\n%s", output, expected)
+		}
+	})
+
+	t.Run("Subdirectory", func(t *testing.T) {
+		config := config
+		config.DirPath = "pkg/printer"
+		config.ExcludePatterns = []string{"*_test.go"}
+		output := captureOutput(func() { PrintFS(testProjectFS(), config) })
+
+		expected := "printer/\n" +
+			"└── printer.go\n" +
+			"\n0 directories, 1 files\n"
+		if output != expected {
+			t.Errorf("Unexpected output:\nGot:\n%s\nExpected:\n%s", output, expected)
+		}
+	})
+}
+
+// TestBuildTree tests the tree built from an fs.FS.
+func TestBuildTree(t *testing.T) {
+	tree, err := BuildTree(testProjectFS(), Config{SortBy: "name", Order: "asc", IncludeHidden: true, MaxDepth: 1})
+	if err != nil {
+		t.Fatalf("BuildTree failed: %v", err)
+	}
+
+	var names []string
+	for _, child := range tree.Children {
+		names = append(names, child.Name)
+		if len(child.Children) != 0 {
+			t.Errorf("Expected %s to have no children at depth 1", child.Name)
+		}
+	}
+	expected := []string{".git", "bin", "cmd", "go.mod", "internal", "pkg"}
+	if len(names) != len(expected) {
+		t.Fatalf("Unexpected children: %v", names)
+	}
+	for i := range expected {
+		if names[i] != expected[i] {
+			t.Errorf("Unexpected children: %v", names)
+			break
+		}
+	}
+
+	// The zero value of MaxDepth reads only the root; -1 walks everything
+	if tree, err := BuildTree(testProjectFS(), Config{}); err != nil || len(tree.Children) != 0 {
+		t.Errorf("Expected only the root with a zero Config, got %v, %v", tree, err)
+	}
+	if tree, _ := BuildTree(testProjectFS(), Config{MaxDepth: -1}); !strings.Contains(listTree(tree), "pkg/printer/printer.go") {
+		t.Errorf("Expected the whole tree with MaxDepth -1, got %q", listTree(tree))
+	}
+
+	if _, err := BuildTree(testProjectFS(), Config{DirPath: "go.mod", MaxDepth: -1}); err == nil {
+		t.Errorf("Expected an error when the root is a file")
+	}
+}
+
+// TestDirFSSymlink tests that DirFS reports symlinks with their targets.
+func TestDirFSSymlink(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(tmpDir, "real"), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.Symlink("real", filepath.Join(tmpDir, "link")); err != nil {
+		t.Skipf("Symlinks not supported: %v", err)
+	}
+
+	output := captureOutput(func() {
+		PrintFS(DirFS(tmpDir), Config{NoColor: true, OutputFormat: "text", SortBy: "name", Order: "asc", MaxDepth: -1})
+	})
+
+	expected := "./\n" +
+		"├── link -> real\n" +
+		"└── real/\n" +
+		"\n1 directories, 1 files\n"
+	if output != expected {
+		t.Errorf("Unexpected output:\nGot:\n%s\nExpected:\n%s", output, expected)
+	}
+}
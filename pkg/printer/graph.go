@@ -0,0 +1,129 @@
+package printer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// graphNode is an entry of a dot or mermaid diagram. Summary nodes stand in
+// for the files of a directory with --collapse-files, or for everything below
+// a directory cut off by --graph-depth.
+type graphNode struct {
+	id     string
+	label  string
+	kind   string // "dir", "file" or "summary"
+	parent string // id of the parent, empty for the root
+}
+
+// graphNodes flattens the tree into the nodes of a diagram, applying the
+// collapse and depth options. Ids are assigned in depth-first order.
+func graphNodes(tree *Node, config Config) []graphNode {
+	var nodes []graphNode
+	add := func(label, kind, parent string) string {
+		id := fmt.Sprintf("n%d", len(nodes))
+		nodes = append(nodes, graphNode{id: id, label: label, kind: kind, parent: parent})
+		return id
+	}
+
+	var visit func(node *Node, id string, depth int)
+	visit = func(node *Node, id string, depth int) {
+		if config.GraphDepth > 0 && depth >= config.GraphDepth {
+			if n := countEntries(node); n > 0 {
+				add(plural(n, "entry", "entries"), "summary", id)
+			}
+			return
+		}
+
+		files := 0
+		for _, child := range node.Children {
+			switch {
+			case child.IsDir:
+				visit(child, add(child.Name+"/", "dir", id), depth+1)
+			case config.CollapseFiles:
+				files++
+			default:
+				add(child.Name, "file", id)
+			}
+		}
+		if files > 0 {
+			add(plural(files, "file", "files"), "summary", id)
+		}
+	}
+	visit(tree, add(tree.Name+"/", "dir", ""), 0)
+	return nodes
+}
+
+// countEntries returns the number of entries below node.
+func countEntries(node *Node) int {
+	n := len(node.Children)
+	for _, child := range node.Children {
+		n += countEntries(child)
+	}
+	return n
+}
+
+// plural formats a count such as "1 file" or "3 files".
+func plural(n int, singular, many string) string {
+	if n == 1 {
+		return "1 " + singular
+	}
+	return fmt.Sprintf("%d %s", n, many)
+}
+
+// dotShapes are the Graphviz shapes of each kind of graph node.
+var dotShapes = map[string]string{
+	"dir":     "folder",
+	"file":    "note",
+	"summary": "plaintext",
+}
+
+// getDotOutput renders the tree as a Graphviz digraph.
+func getDotOutput(tree *Node, config Config) string {
+	escape := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace
+
+	var sb strings.Builder
+	sb.WriteString("digraph tree {\n")
+	sb.WriteString("  rankdir=LR;\n")
+	sb.WriteString("  node [fontname=\"Helvetica\"];\n")
+	nodes := graphNodes(tree, config)
+	for _, n := range nodes {
+		fmt.Fprintf(&sb, "  %s [label=\"%s\", shape=%s];\n", n.id, escape(n.label), dotShapes[n.kind])
+	}
+	for _, n := range nodes {
+		if n.parent != "" {
+			fmt.Fprintf(&sb, "  %s -> %s;\n", n.parent, n.id)
+		}
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// getMermaidOutput renders the tree as a Mermaid flowchart in a ```mermaid
+// code fence, ready to paste into Markdown. Directories are rectangles, files
+// have rounded corners and summaries are stadiums.
+func getMermaidOutput(tree *Node, config Config) string {
+	escape := strings.NewReplacer(`"`, "#quot;").Replace
+
+	var sb strings.Builder
+	sb.WriteString("```mermaid\ngraph LR\n")
+	nodes := graphNodes(tree, config)
+	for _, n := range nodes {
+		label := `"` + escape(n.label) + `"`
+		switch n.kind {
+		case "dir":
+			label = "[" + label + "]"
+		case "file":
+			label = "(" + label + ")"
+		default:
+			label = "([" + label + "])"
+		}
+		fmt.Fprintf(&sb, "  %s%s\n", n.id, label)
+	}
+	for _, n := range nodes {
+		if n.parent != "" {
+			fmt.Fprintf(&sb, "  %s --> %s\n", n.parent, n.id)
+		}
+	}
+	sb.WriteString("```\n")
+	return sb.String()
+}
@@ -0,0 +1,85 @@
+package printer
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+// TestGraphFormats tests the dot and mermaid diagram formats.
+func TestGraphFormats(t *testing.T) {
+	fsys := fstest.MapFS{
+		"README.md":         {},
+		"cmd/main.go":       {},
+		"pkg/a/a.go":        {},
+		"pkg/a/a_test.go":   {},
+		"pkg/\"quoted\".go": {},
+	}
+	config := Config{SortBy: "name", Order: "asc", MaxDepth: -1}
+
+	t.Run("Dot", func(t *testing.T) {
+		config := config
+		config.OutputFormat = "dot"
+		config.DirPath = "cmd"
+		output := captureOutput(func() { PrintFS(fsys, config) })
+
+		expected := "digraph tree {\n" +
+			"  rankdir=LR;\n" +
+			"  node [fontname=\"Helvetica\"];\n" +
+			"  n0 [label=\"cmd/\", shape=folder];\n" +
+			"  n1 [label=\"main.go\", shape=note];\n" +
+			"  n0 -> n1;\n" +
+			"}\n"
+		if output != expected {
+			t.Errorf("Unexpected output:\nGot:\n%s\nExpected:\n%s", output, expected)
+		}
+	})
+
+	t.Run("Mermaid", func(t *testing.T) {
+		config := config
+		config.OutputFormat = "mermaid"
+		config.DirPath = "pkg"
+		output := captureOutput(func() { PrintFS(fsys, config) })
+
+		expected := "```mermaid\n" +
+			"graph LR\n" +
+			"  n0[\"pkg/\"]\n" +
+			"  n1(\"#quot;quoted#quot;.go\")\n" +
+			"  n2[\"a/\"]\n" +
+			"  n3(\"a.go\")\n" +
+			"  n4(\"a_test.go\")\n" +
+			"  n0 --> n1\n" +
+			"  n0 --> n2\n" +
+			"  n2 --> n3\n" +
+			"  n2 --> n4\n" +
+			"```\n"
+		if output != expected {
+			t.Errorf("Unexpected output:\nGot:\n%s\nExpected:\n%s", output, expected)
+		}
+	})
+
+	t.Run("CollapseAndDepth", func(t *testing.T) {
+		config := config
+		config.OutputFormat = "mermaid"
+		config.CollapseFiles = true
+		config.GraphDepth = 1
+		output := captureOutput(func() { PrintFS(fsys, config) })
+
+		expected := "```mermaid\n" +
+			"graph LR\n" +
+			"  n0[\"./\"]\n" +
+			"  n1[\"cmd/\"]\n" +
+			"  n2([\"1 entry\"])\n" +
+			"  n3[\"pkg/\"]\n" +
+			"  n4([\"4 entries\"])\n" +
+			"  n5([\"1 file\"])\n" +
+			"  n0 --> n1\n" +
+			"  n1 --> n2\n" +
+			"  n0 --> n3\n" +
+			"  n3 --> n4\n" +
+			"  n0 --> n5\n" +
+			"```\n"
+		if output != expected {
+			t.Errorf("Unexpected output:\nGot:\n%s\nExpected:\n%s", output, expected)
+		}
+	})
+}
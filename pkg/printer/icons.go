@@ -0,0 +1,218 @@
+package printer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// IconTable maps entries to the glyphs shown before their names with --icons.
+// Lookups try the exact directory or file name first, then the extension,
+// then fall back to the Directory or File icon.
+type IconTable struct {
+	Directory   string            `yaml:"directory"`
+	File        string            `yaml:"file"`
+	Directories map[string]string `yaml:"directories"` // keyed by directory name, e.g. ".git"
+	Files       map[string]string `yaml:"files"`       // keyed by file name, e.g. "go.mod"
+	Extensions  map[string]string `yaml:"extensions"`  // keyed by lowercase extension, e.g. ".go"
+}
+
+// iconSets are the built-in tables selectable with --icon-set.
+var iconSets = map[string]IconTable{
+	// Nerd Font glyphs; they need a patched font to display.
+	"nerd": {
+		Directory: "\uf07b",
+		File:      "\uf15b",
+		Directories: map[string]string{
+			".git":         "\uf1d3",
+			".github":      "\uf09b",
+			"node_modules": "\ue718",
+			"src":          "\uf121",
+			"cmd":          "\uf120",
+			"docs":         "\uf02d",
+			"test":         "\uf0c3",
+			"tests":        "\uf0c3",
+			"config":       "\uf013",
+		},
+		Files: map[string]string{
+			"go.mod":             "\ue627",
+			"go.sum":             "\ue627",
+			"Dockerfile":         "\ue7b0",
+			"docker-compose.yml": "\ue7b0",
+			"Makefile":           "\uf120",
+			".gitignore":         "\uf1d3",
+			".gitattributes":     "\uf1d3",
+			"LICENSE":            "\uf02d",
+			"README.md":          "\uf02d",
+			"package.json":       "\ue718",
+			"Cargo.toml":         "\ue7a8",
+		},
+		Extensions: map[string]string{
+			".go":   "\ue627",
+			".py":   "\ue606",
+			".js":   "\ue74e",
+			".ts":   "\ue628",
+			".rs":   "\ue7a8",
+			".java": "\ue738",
+			".rb":   "\ue739",
+			".c":    "\ue61e",
+			".h":    "\ue61e",
+			".cpp":  "\ue61d",
+			".html": "\ue736",
+			".css":  "\ue749",
+			".json": "\ue60b",
+			".md":   "\ue609",
+			".yaml": "\uf013",
+			".yml":  "\uf013",
+			".toml": "\uf013",
+			".sh":   "\uf120",
+			".sql":  "\uf1c0",
+			".txt":  "\uf0f6",
+			".pdf":  "\uf1c1",
+			".png":  "\uf1c5",
+			".jpg":  "\uf1c5",
+			".jpeg": "\uf1c5",
+			".gif":  "\uf1c5",
+			".svg":  "\uf1c5",
+			".zip":  "\uf1c6",
+			".tar":  "\uf1c6",
+			".gz":   "\uf1c6",
+			".zst":  "\uf1c6",
+			".jar":  "\uf1c6",
+			".lock": "\uf023",
+		},
+	},
+	// Emoji work with any font that has color emoji.
+	"emoji": {
+		Directory: "📁",
+		File:      "📄",
+		Directories: map[string]string{
+			".git":         "🔀",
+			".github":      "🐙",
+			"node_modules": "📦",
+			"docs":         "📚",
+			"test":         "🧪",
+			"tests":        "🧪",
+			"config":       "🔧",
+			"src":          "💻",
+			"cmd":          "🚀",
+		},
+		Files: map[string]string{
+			"go.mod":     "🐹",
+			"go.sum":     "🐹",
+			"Dockerfile": "🐳",
+			"Makefile":   "🔧",
+			".gitignore": "🙈",
+			"LICENSE":    "📜",
+			"README.md":  "📖",
+		},
+		Extensions: map[string]string{
+			".go":   "🐹",
+			".py":   "🐍",
+			".js":   "📜",
+			".ts":   "📜",
+			".rs":   "🦀",
+			".java": "☕",
+			".rb":   "💎",
+			".html": "🌐",
+			".css":  "🎨",
+			".json": "🔧",
+			".md":   "📝",
+			".yaml": "🔧",
+			".yml":  "🔧",
+			".toml": "🔧",
+			".sh":   "🐚",
+			".sql":  "💾",
+			".txt":  "📝",
+			".pdf":  "📕",
+			".png":  "🌄",
+			".jpg":  "🌄",
+			".jpeg": "🌄",
+			".gif":  "🌄",
+			".svg":  "🌄",
+			".zip":  "📦",
+			".tar":  "📦",
+			".gz":   "📦",
+			".zst":  "📦",
+			".jar":  "📦",
+			".lock": "🔒",
+		},
+	},
+}
+
+// getIconTable returns the icon table for the config with the overrides from
+// config.IconOverrides applied, or nil when icons are disabled. Icons are
+// dropped in the ASCII style, which is meant for terminals without Unicode,
+// and with --no-color or NO_COLOR, which ask for plain output.
+func getIconTable(config Config) (*IconTable, error) {
+	if !config.Icons || config.Style == "ascii" || config.NoColor || os.Getenv("NO_COLOR") != "" {
+		return nil, nil
+	}
+
+	base, err := iconSet(config.IconSet)
+	if err != nil {
+		return nil, err
+	}
+
+	var overrides IconTable
+	if config.IconOverrides != nil {
+		overrides = *config.IconOverrides
+	}
+
+	table := &IconTable{
+		Directory:   base.Directory,
+		File:        base.File,
+		Directories: mergeIcons(base.Directories, overrides.Directories),
+		Files:       mergeIcons(base.Files, overrides.Files),
+		Extensions:  mergeIcons(base.Extensions, overrides.Extensions),
+	}
+	if overrides.Directory != "" {
+		table.Directory = overrides.Directory
+	}
+	if overrides.File != "" {
+		table.File = overrides.File
+	}
+	return table, nil
+}
+
+// iconSet returns the built-in icon table called name; empty means nerd.
+func iconSet(name string) (IconTable, error) {
+	if name == "" {
+		name = "nerd"
+	}
+	base, ok := iconSets[name]
+	if !ok {
+		return IconTable{}, fmt.Errorf("unknown icon set %q (available: nerd, emoji)", name)
+	}
+	return base, nil
+}
+
+// mergeIcons returns a copy of base with the entries of overrides added.
+func mergeIcons(base, overrides map[string]string) map[string]string {
+	merged := make(map[string]string, len(base)+len(overrides))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range overrides {
+		merged[k] = v
+	}
+	return merged
+}
+
+// icon returns the glyph for a node.
+func (t *IconTable) icon(node *Node) string {
+	if node.IsDir {
+		if icon, ok := t.Directories[node.Name]; ok {
+			return icon
+		}
+		return t.Directory
+	}
+	if icon, ok := t.Files[node.Name]; ok {
+		return icon
+	}
+	if icon, ok := t.Extensions[strings.ToLower(filepath.Ext(node.Name))]; ok {
+		return icon
+	}
+	return t.File
+}
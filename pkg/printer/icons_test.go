@@ -0,0 +1,108 @@
+package printer
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+// TestIcons tests the icon lookup order, overrides and the plain fallbacks.
+func TestIcons(t *testing.T) {
+	fsys := fstest.MapFS{
+		".git/HEAD":  {},
+		"Dockerfile": {},
+		"main.go":    {},
+		"notes.xyz":  {},
+		"src/app.rs": {},
+	}
+	// Icons need colors allowed; stdout is not a terminal, so none are printed
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("CLICOLOR_FORCE", "")
+	config := Config{OutputFormat: "text", SortBy: "name", Order: "asc", IncludeHidden: true, MaxDepth: -1, Icons: true, IconSet: "emoji"}
+
+	t.Run("Emoji", func(t *testing.T) {
+		output := captureOutput(func() { PrintFS(fsys, config) })
+
+		expected := "./\n" +
+			"├── 🔀 .git/\n" +
+			"│   └── 📄 HEAD\n" +
+			"├── 🐳 Dockerfile\n" +
+			"├── 🐹 main.go\n" +
+			"├── 📄 notes.xyz\n" +
+			"└── 💻 src/\n" +
+			"    └── 🦀 app.rs\n" +
+			"\n2 directories, 5 files\n"
+		if output != expected {
+			t.Errorf("Unexpected output:\nGot:\n%s\nExpected:\n%s", output, expected)
+		}
+	})
+
+	t.Run("Overrides", func(t *testing.T) {
+		config := config
+		config.IncludeHidden = false
+		config.IconOverrides = &IconTable{
+			Directory:   "D",
+			Directories: map[string]string{"src": "S"},
+			Extensions:  map[string]string{".xyz": "X"},
+		}
+		output := captureOutput(func() { PrintFS(fsys, config) })
+
+		expected := "./\n" +
+			"├── 🐳 Dockerfile\n" +
+			"├── 🐹 main.go\n" +
+			"├── X notes.xyz\n" +
+			"└── S src/\n" +
+			"    └── 🦀 app.rs\n" +
+			"\n1 directories, 4 files\n"
+		if output != expected {
+			t.Errorf("Unexpected output:\nGot:\n%s\nExpected:\n%s", output, expected)
+		}
+	})
+
+	t.Run("NamedDirectories", func(t *testing.T) {
+		table := iconSets["emoji"]
+		for name, expected := range map[string]string{"src": "💻", "cmd": "🚀", "docs": "📚", "lib": "📁"} {
+			if got := table.icon(&Node{Name: name, IsDir: true}); got != expected {
+				t.Errorf("icon(%s/) = %s, expected %s", name, got, expected)
+			}
+		}
+	})
+
+	t.Run("ASCIIFallback", func(t *testing.T) {
+		config := config
+		config.IncludeHidden = false
+		config.Style = "ascii"
+		output := captureOutput(func() { PrintFS(fsys, config) })
+
+		expected := "./\n" +
+			"|-- Dockerfile\n" +
+			"|-- main.go\n" +
+			"|-- notes.xyz\n" +
+			"`-- src/\n" +
+			"    `-- app.rs\n" +
+			"\n1 directories, 4 files\n"
+		if output != expected {
+			t.Errorf("Unexpected output:\nGot:\n%s\nExpected:\n%s", output, expected)
+		}
+	})
+
+	t.Run("NoColorFallback", func(t *testing.T) {
+		config := config
+		config.NoColor = true
+		if table, err := getIconTable(config); table != nil || err != nil {
+			t.Errorf("Expected no icons with --no-color, got %v, %v", table, err)
+		}
+
+		t.Setenv("NO_COLOR", "1")
+		if table, err := getIconTable(Config{Icons: true}); table != nil || err != nil {
+			t.Errorf("Expected no icons with NO_COLOR, got %v, %v", table, err)
+		}
+	})
+
+	t.Run("UnknownSet", func(t *testing.T) {
+		config := config
+		config.IconSet = "wingdings"
+		if _, err := getIconTable(config); err == nil {
+			t.Errorf("Expected an error for an unknown icon set")
+		}
+	})
+}
@@ -0,0 +1,261 @@
+package printer
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// layoutFS is the file system described by a previously printed json, yaml
+// or xml document, or by a text tree. Like an archive it only holds
+// metadata, so everything but file contents can be rendered again.
+type layoutFS struct {
+	*archiveFS
+	errs map[string]string // errors recorded for unreadable directories
+	name string            // name of the root entry
+	root string            // the originally scanned path, if known
+}
+
+// ReadLayout reads a document printed by the json, yaml or xml formats,
+// versioned or legacy, or a text tree accepted by ParseTextTree, and returns
+// the file system it describes. The format is detected from the content.
+// Symlink targets are not resolved.
+func ReadLayout(r io.Reader) (FS, error) {
+	return readLayout(r)
+}
+
+// openLayout reads the layout file at name.
+func openLayout(name string) (*layoutFS, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	layout, err := readLayout(f)
+	if err != nil {
+		return nil, fmt.Errorf("reading layout %s: %w", name, err)
+	}
+	return layout, nil
+}
+
+func readLayout(r io.Reader) (*layoutFS, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc *Document
+	if looksLikeTextTree(data) {
+		var tree *Node
+		tree, err = ParseTextTree(bytes.NewReader(data))
+		doc = &Document{}
+		if tree != nil {
+			doc.Tree = newEntry(tree)
+		}
+	} else {
+		doc, err = decodeLayout(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if doc.Tree == nil {
+		return nil, errors.New("the document has no tree")
+	}
+	if doc.SchemaVersion > SchemaVersion {
+		return nil, fmt.Errorf("unsupported schema version %d (this version of pr reads up to %d)", doc.SchemaVersion, SchemaVersion)
+	}
+
+	layout := &layoutFS{
+		archiveFS: newArchiveFS(),
+		errs:      map[string]string{},
+		name:      doc.Tree.Name,
+		root:      doc.Root,
+	}
+	if layout.root == "" && path.IsAbs(doc.Tree.Path) {
+		layout.root = doc.Tree.Path
+	}
+	root := layout.entries["."]
+	root.mode, root.modTime = entryMode(doc.Tree), entryTime(doc.Tree)
+	layout.addChildren(".", doc.Tree)
+	return layout, nil
+}
+
+// decodeLayout parses a versioned Document or a legacy Node tree in any of
+// the structured formats. Legacy trees are converted to a Document without
+// metadata.
+func decodeLayout(data []byte) (*Document, error) {
+	var doc Document
+	var legacy Node
+	trimmed := bytes.TrimSpace(data)
+
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		var probe struct{ XMLName xml.Name }
+		if err := xml.Unmarshal(trimmed, &probe); err != nil {
+			return nil, err
+		}
+		if probe.XMLName.Local == "layout" {
+			if err := xml.Unmarshal(trimmed, &doc); err != nil {
+				return nil, err
+			}
+			setEntryTypes(doc.Tree)
+			return &doc, nil
+		}
+		if err := xml.Unmarshal(trimmed, &legacy); err != nil {
+			return nil, err
+		}
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		var probe struct {
+			SchemaVersion int `json:"schema_version"`
+		}
+		if err := json.Unmarshal(trimmed, &probe); err != nil {
+			return nil, err
+		}
+		if probe.SchemaVersion > 0 {
+			if err := json.Unmarshal(trimmed, &doc); err != nil {
+				return nil, err
+			}
+			return &doc, nil
+		}
+		if err := json.Unmarshal(trimmed, &legacy); err != nil {
+			return nil, err
+		}
+	default:
+		var probe struct {
+			SchemaVersion int `yaml:"schema_version"`
+		}
+		if err := yaml.Unmarshal(trimmed, &probe); err != nil {
+			return nil, err
+		}
+		if probe.SchemaVersion > 0 {
+			if err := yaml.Unmarshal(trimmed, &doc); err != nil {
+				return nil, err
+			}
+			return &doc, nil
+		}
+		if err := yaml.Unmarshal(trimmed, &legacy); err != nil {
+			return nil, err
+		}
+	}
+
+	if legacy.Name == "" && len(legacy.Children) == 0 {
+		return &Document{}, nil
+	}
+	return &Document{Tree: legacyEntry(&legacy)}, nil
+}
+
+// setEntryTypes fills in the types of entries read from XML, where the type
+// is the element name.
+func setEntryTypes(entry *Entry) {
+	if entry == nil {
+		return
+	}
+	entry.Type = entry.XMLName.Local
+	for _, child := range entry.Children {
+		setEntryTypes(child)
+	}
+}
+
+// legacyEntry converts a tree printed with LegacyOutput.
+func legacyEntry(node *Node) *Entry {
+	entry := &Entry{Name: node.Name, Path: node.Path, Type: "file", LinkTarget: node.LinkTarget}
+	switch {
+	case node.IsDir:
+		entry.Type = "dir"
+	case node.LinkTarget != "":
+		entry.Type = "symlink"
+	}
+	for _, child := range node.Children {
+		entry.Children = append(entry.Children, legacyEntry(child))
+	}
+	return entry
+}
+
+// addChildren adds the children of entry, which lives at dir in the layout.
+func (l *layoutFS) addChildren(dir string, entry *Entry) {
+	if entry.Error != "" {
+		l.errs[dir] = entry.Error
+	}
+	for _, child := range entry.Children {
+		if child.Name == "" {
+			continue
+		}
+		name := path.Join(dir, child.Name)
+		var size int64
+		if child.Size != nil {
+			size = *child.Size
+		}
+		l.add(name, &archiveEntry{name: child.Name, size: size, mode: entryMode(child), modTime: entryTime(child)}, child.LinkTarget)
+		l.addChildren(name, child)
+	}
+}
+
+// entryMode returns the file mode of an entry from its type and mode string.
+func entryMode(entry *Entry) fs.FileMode {
+	var mode fs.FileMode
+	switch entry.Type {
+	case "dir":
+		mode = fs.ModeDir
+	case "symlink":
+		mode = fs.ModeSymlink
+	case "other":
+		mode = fs.ModeIrregular
+	}
+	if perm, ok := parsePermString(entry.Mode); ok {
+		return mode | perm
+	}
+	if entry.Type == "dir" {
+		return mode | 0755
+	}
+	return mode | 0644
+}
+
+// entryTime parses the modification time of an entry, if it has one.
+func entryTime(entry *Entry) time.Time {
+	t, _ := time.Parse(time.RFC3339, entry.ModTime)
+	return t
+}
+
+// parsePermString is the inverse of permString for the permission and
+// special bits; the type character is ignored.
+func parsePermString(s string) (fs.FileMode, bool) {
+	if len(s) != 10 {
+		return 0, false
+	}
+	var mode fs.FileMode
+	const rwx = "rwxrwxrwx"
+	for i := 0; i < 9; i++ {
+		c := s[i+1]
+		switch {
+		case c == rwx[i]:
+			mode |= 1 << uint(8-i)
+		case i%3 == 2 && (c == 's' || c == 't'):
+			mode |= 1 << uint(8-i)
+			fallthrough
+		case i%3 == 2 && (c == 'S' || c == 'T'):
+			mode |= [...]fs.FileMode{fs.ModeSetuid, fs.ModeSetgid, fs.ModeSticky}[i/3]
+		case c != '-':
+			return 0, false
+		}
+	}
+	return mode, true
+}
+
+// ReadDir implements fs.ReadDirFS, failing for directories that could not
+// be read when the layout was recorded.
+func (l *layoutFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if msg, ok := l.errs[name]; ok {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New(msg)}
+	}
+	return l.archiveFS.ReadDir(name)
+}
@@ -0,0 +1,111 @@
+package printer
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// TestRenderLayout tests rendering a tree read back from each structured
+// format, versioned and legacy.
+func TestRenderLayout(t *testing.T) {
+	modTime := time.Date(2025, 1, 2, 15, 4, 0, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"bin":         {Mode: fs.ModeDir | 0755, ModTime: modTime},
+		"bin/tool":    {Mode: fs.ModeSetuid | 0755, Data: make([]byte, 300), ModTime: modTime},
+		"src/main.go": {Mode: 0644, Data: make([]byte, 20), ModTime: modTime.Add(time.Hour)},
+		"src/util.go": {Mode: 0644, Data: make([]byte, 10), ModTime: modTime},
+		"src/link":    {Mode: fs.ModeSymlink | 0777, Data: []byte("main.go")},
+		".env":        {Mode: 0600, ModTime: modTime},
+	}
+	text := Config{NoColor: true, OutputFormat: "text", SortBy: "name", Order: "asc", MaxDepth: -1}
+
+	// The expected output is the same tree printed straight from fsys
+	expected := func(config Config) string {
+		return captureOutput(func() { PrintFS(fsys, config) })
+	}
+
+	for _, format := range []string{"json", "yaml", "xml"} {
+		for _, legacy := range []bool{false, true} {
+			config := Config{OutputFormat: format, SortBy: "name", Order: "asc", MaxDepth: -1, IncludeHidden: true, LegacyOutput: legacy}
+			input := filepath.Join(t.TempDir(), "layout."+format)
+			if err := os.WriteFile(input, []byte(expected(config)), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			// Filters, sorting and depth limits apply to the layout
+			config = text
+			config.Input = input
+			config.SortBy, config.Order = "size", "desc"
+			config.ExtFilter = ".go"
+			output := captureOutput(func() { HandleFlags(config) })
+
+			want := text
+			want.SortBy, want.Order = "size", "desc"
+			want.ExtFilter = ".go"
+			if legacy {
+				// Legacy documents have no sizes, so the order is by name
+				want.SortBy, want.Order = "name", "asc"
+			}
+			if exp := expected(want); output != exp {
+				t.Errorf("%s (legacy %v): unexpected output:\nGot:\n%s\nExpected:\n%s", format, legacy, output, exp)
+			}
+
+			if !legacy {
+				config.ExtFilter = ""
+				config.SortBy, config.Order = "name", "asc"
+				config.Long = true
+				config.IncludeHidden = true
+				config.MaxDepth = 1
+				output = captureOutput(func() { HandleFlags(config) })
+				want := config
+				want.Input = ""
+				if exp := expected(want); output != exp {
+					t.Errorf("%s long: unexpected output:\nGot:\n%s\nExpected:\n%s", format, output, exp)
+				}
+			}
+		}
+	}
+}
+
+// TestReadLayoutErrors tests documents that cannot be rendered.
+func TestReadLayoutErrors(t *testing.T) {
+	for input, expected := range map[string]string{
+		`{"schema_version": 2, "tree": {"name": "x", "path": ".", "type": "dir"}}`: "unsupported schema version 2",
+		`{"schema_version": 1}`: "the document has no tree",
+		`<layout`:               "EOF",
+	} {
+		_, err := ReadLayout(strings.NewReader(input))
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("ReadLayout(%s) = %v, expected an error containing %q", input, err, expected)
+		}
+	}
+
+	// Directories that could not be read stay unreadable
+	layout, err := ReadLayout(strings.NewReader(`{"schema_version": 1, "tree": {"name": "x", "path": ".", "type": "dir",
+		"children": [{"name": "secret", "path": "secret", "type": "dir", "error": "permission denied"}]}}`))
+	if err != nil {
+		t.Fatalf("ReadLayout failed: %v", err)
+	}
+	output := captureOutput(func() { PrintFS(layout, Config{NoColor: true, OutputFormat: "text", MaxDepth: -1}) })
+	if expected := "./\n└── secret/ [error opening dir]\n\n1 directories, 0 files\n"; output != expected {
+		t.Errorf("Unexpected output:\nGot:\n%s\nExpected:\n%s", output, expected)
+	}
+}
+
+// TestParsePermString tests reading back the permission strings.
+func TestParsePermString(t *testing.T) {
+	for _, mode := range []fs.FileMode{0644, 0755, fs.ModeSetuid | 0755, fs.ModeSetgid | 0640, fs.ModeSticky | 0777, fs.ModeSticky | 0776} {
+		got, ok := parsePermString(permString(mode, false))
+		if !ok || got != mode {
+			t.Errorf("parsePermString(%s) = %v, expected %v", permString(mode, false), got, mode)
+		}
+	}
+	if _, ok := parsePermString("-rwxr-xr-q"); ok {
+		t.Error("Expected an invalid permission string to be rejected")
+	}
+}
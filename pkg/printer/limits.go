@@ -0,0 +1,60 @@
+package printer
+
+import "fmt"
+
+// Reasons for a Truncation.
+const (
+	truncatedFileLimit  = "filelimit"
+	truncatedMaxEntries = "max_entries"
+)
+
+// Truncation records the entries of a directory left out of the tree by
+// Config.FileLimit or Config.MaxEntries.
+type Truncation struct {
+	Reason  string `json:"reason" yaml:"reason" xml:"reason,attr"`                         // "filelimit" or "max_entries"
+	Entries int    `json:"entries" yaml:"entries" xml:"entries,attr"`                      // number of entries left out
+	Size    int64  `json:"size,omitempty" yaml:"size,omitempty" xml:"size,attr,omitempty"` // bytes of the files left out, for max_entries
+}
+
+// String describes the truncation for the text output.
+func (t *Truncation) String() string {
+	if t.Reason == truncatedFileLimit {
+		return fmt.Sprintf("[%s exceeds filelimit, not opening dir]", plural(t.Entries, "entry", "entries"))
+	}
+	return fmt.Sprintf("… and %d more (%s)", t.Entries, byteSize(t.Size))
+}
+
+// byteSize formats a byte count like humanSize but always with a unit,
+// e.g. 218 B or 56 MB.
+func byteSize(size int64) string {
+	s := humanSize(size)
+	if size < 1024 {
+		return s + " B"
+	}
+	return s[:len(s)-1] + " " + s[len(s)-1:] + "B"
+}
+
+// exceedsFileLimit reports whether a directory below the root has more
+// entries than config.FileLimit and is not read, recording why on node.
+func exceedsFileLimit(node *Node, entries int, config Config, depth int) bool {
+	if config.FileLimit <= 0 || depth == 0 || entries <= config.FileLimit {
+		return false
+	}
+	node.truncated = &Truncation{Reason: truncatedFileLimit, Entries: entries}
+	return true
+}
+
+// limitEntries keeps the first config.MaxEntries children of node and
+// records how many entries and bytes the others held.
+func limitEntries(node *Node, config Config) {
+	if config.MaxEntries <= 0 || len(node.Children) <= config.MaxEntries {
+		return
+	}
+	omitted := node.Children[config.MaxEntries:]
+	truncation := &Truncation{Reason: truncatedMaxEntries, Entries: len(omitted)}
+	for _, child := range omitted {
+		truncation.Size += newSizeIndex(child)[child]
+	}
+	node.Children = node.Children[:config.MaxEntries:config.MaxEntries]
+	node.truncated = truncation
+}
@@ -0,0 +1,111 @@
+package printer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// limitsFS returns a project with a large dependency directory.
+func limitsFS() fstest.MapFS {
+	fsys := fstest.MapFS{
+		"main.go":   {Data: make([]byte, 100)},
+		"go.mod":    {Data: make([]byte, 20)},
+		"README.md": {Data: make([]byte, 3000)},
+	}
+	for i := 0; i < 12; i++ {
+		fsys[fmt.Sprintf("assets/img%02d.png", i)] = &fstest.MapFile{Data: make([]byte, 1024*(i+1))}
+	}
+	fsys["assets/icons/a.svg"] = &fstest.MapFile{Data: make([]byte, 2048)}
+	for i := 0; i < 30; i++ {
+		fsys[fmt.Sprintf("node_modules/pkg%02d/index.js", i)] = &fstest.MapFile{Data: make([]byte, 10)}
+	}
+	return fsys
+}
+
+// TestFileLimit tests that large directories below the root are not read.
+func TestFileLimit(t *testing.T) {
+	config := Config{SortBy: "name", Order: "asc", MaxDepth: -1, FileLimit: 13}
+	tree, err := BuildTree(limitsFS(), config)
+	if err != nil {
+		t.Fatalf("BuildTree failed: %v", err)
+	}
+	output := getTreeOutput(tree, textOptions{colors: plainPalette, style: treeStyles["ascii"]})
+	expected := "./\n" +
+		"|-- README.md\n" +
+		"|-- assets/\n" +
+		"|   |-- icons/\n" +
+		"|   |   `-- a.svg\n"
+	if !strings.HasPrefix(output, expected) {
+		t.Errorf("Expected assets to be read, got:\n%s", output)
+	}
+	if !strings.Contains(output, "`-- node_modules/ [30 entries exceeds filelimit, not opening dir]\n") {
+		t.Errorf("Expected node_modules not to be read, got:\n%s", output)
+	}
+
+	// The root is always read
+	config.FileLimit = 2
+	tree, _ = BuildTree(limitsFS(), config)
+	if got, expected := listTree(tree), "README.md assets/ go.mod main.go node_modules/"; got != expected {
+		t.Errorf("got %q, expected %q", got, expected)
+	}
+}
+
+// TestMaxEntries tests the children shown per directory and the summary of
+// the others.
+func TestMaxEntries(t *testing.T) {
+	config := Config{SortBy: "name", Order: "asc", MaxDepth: -1, MaxEntries: 3, FileLimit: 20}
+	tree, err := BuildTree(limitsFS(), config)
+	if err != nil {
+		t.Fatalf("BuildTree failed: %v", err)
+	}
+	output := getTreeOutput(tree, textOptions{colors: plainPalette, style: treeStyles["ascii"]})
+	expected := "./\n" +
+		"|-- README.md\n" +
+		"|-- assets/\n" +
+		"|   |-- icons/\n" +
+		"|   |   `-- a.svg\n" +
+		"|   |-- img00.png\n" +
+		"|   |-- img01.png\n" +
+		"|   `-- … and 10 more (75 KB)\n" +
+		"|-- go.mod\n" +
+		"`-- … and 2 more (100 B)\n" +
+		"\n" +
+		"2 directories, 5 files\n"
+	if output != expected {
+		t.Errorf("got:\n%s\nexpected:\n%s", output, expected)
+	}
+
+	data, err := json.Marshal(newDocument(tree, ".", config))
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	for _, expected := range []string{
+		`"filelimit":20,"max_entries":3`,
+		`"path":".","type":"dir","mode":"dr-xr-xr-x","truncated":{"reason":"max_entries","entries":2,"size":100}`,
+		`"path":"assets","type":"dir","mode":"dr-xr-xr-x","truncated":{"reason":"max_entries","entries":10,"size":76800}`,
+	} {
+		if !strings.Contains(string(data), expected) {
+			t.Errorf("Expected %s in %s", expected, data)
+		}
+	}
+}
+
+// TestByteSize tests that the sizes of truncation markers always have a unit.
+func TestByteSize(t *testing.T) {
+	tests := map[int64]string{
+		0:                "0 B",
+		218:              "218 B",
+		1536:             "1.5 KB",
+		75 * 1024:        "75 KB",
+		56 * 1024 * 1024: "56 MB",
+		3 << 40:          "3.0 TB",
+	}
+	for size, expected := range tests {
+		if got := byteSize(size); got != expected {
+			t.Errorf("byteSize(%d) = %s, expected %s", size, got, expected)
+		}
+	}
+}
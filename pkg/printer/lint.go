@@ -0,0 +1,326 @@
+package printer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RuleSet is a layout policy read from a rules file with LoadRules.
+type RuleSet struct {
+	Exclude []string `yaml:"exclude"` // entries skipped during the walk, as with --exclude
+	Rules   []*Rule  `yaml:"rules"`
+}
+
+// Rule is one check of a RuleSet. A rule may combine several checks; every
+// failure is reported with the rule's id and severity.
+type Rule struct {
+	ID       string `yaml:"id"`
+	Severity string `yaml:"severity"` // "error" (default), "warning" or "note"
+	Message  string `yaml:"message"`  // replaces the generated message
+
+	// Selectors. Patterns without a slash match entry names anywhere;
+	// patterns with a slash match paths from the root, where ** matches any
+	// number of directories. Empty selectors match everything.
+	Dirs  []string `yaml:"dirs"`  // directories checked by require and allowed_extensions
+	Paths []string `yaml:"paths"` // entries checked by name_pattern, max_size and max_depth
+
+	Require           []string `yaml:"require"`            // name patterns each selected directory must contain
+	Forbid            []string `yaml:"forbid"`             // patterns no entry may match
+	AllowedExtensions []string `yaml:"allowed_extensions"` // the only extensions allowed for files in selected directories
+	NamePattern       string   `yaml:"name_pattern"`       // regular expression selected names must match
+	MaxSize           string   `yaml:"max_size"`           // largest allowed file, e.g. 512K or 10M
+	MaxDepth          int      `yaml:"max_depth"`          // deepest allowed level, 1 for children of the root
+
+	namePattern *regexp.Regexp
+	maxSize     int64
+}
+
+// Violation is a failed check.
+type Violation struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Path     string `json:"path"`
+	Message  string `json:"message"`
+}
+
+// LoadRules reads and validates a YAML rules file.
+func LoadRules(name string) (*RuleSet, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var rules RuleSet
+	if err := yaml.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("parsing rules file %s: %w", name, err)
+	}
+
+	for i, rule := range rules.Rules {
+		if rule.ID == "" {
+			rule.ID = fmt.Sprintf("rule-%d", i+1)
+		}
+		switch rule.Severity {
+		case "":
+			rule.Severity = "error"
+		case "error", "warning", "note":
+		default:
+			return nil, fmt.Errorf("rule %s: unknown severity %q (available: error, warning, note)", rule.ID, rule.Severity)
+		}
+		if rule.NamePattern != "" {
+			if rule.namePattern, err = regexp.Compile(rule.NamePattern); err != nil {
+				return nil, fmt.Errorf("rule %s: %w", rule.ID, err)
+			}
+		}
+		if rule.MaxSize != "" {
+			if rule.maxSize, err = parseSize(rule.MaxSize); err != nil {
+				return nil, fmt.Errorf("rule %s: %w", rule.ID, err)
+			}
+		}
+		patterns := append(append([]string{}, rule.Dirs...), rule.Paths...)
+		for _, pattern := range append(append(patterns, rule.Forbid...), rule.Require...) {
+			if _, err := path.Match(strings.ReplaceAll(pattern, "**", "*"), ""); err != nil {
+				return nil, fmt.Errorf("rule %s: bad pattern %q", rule.ID, pattern)
+			}
+		}
+	}
+	return &rules, nil
+}
+
+// Lint walks fsys with the filters of config and the excludes of the rule
+// set, and returns the violations sorted by path.
+func Lint(fsys fs.FS, rules *RuleSet, config Config) ([]Violation, error) {
+	config.ExcludePatterns = append(append([]string{}, config.ExcludePatterns...), rules.Exclude...)
+	config.MaxDepth = -1
+	tree, err := BuildTree(fsys, config)
+	if err != nil {
+		return nil, err
+	}
+	return lintTree(tree, rules), nil
+}
+
+// lintTree checks every node of the tree against the rules.
+func lintTree(tree *Node, rules *RuleSet) []Violation {
+	var violations []Violation
+	report := func(rule *Rule, p, message string) {
+		if rule.Message != "" {
+			message = rule.Message
+		}
+		violations = append(violations, Violation{Rule: rule.ID, Severity: rule.Severity, Path: p, Message: message})
+	}
+
+	var visit func(node *Node, depth int)
+	visit = func(node *Node, depth int) {
+		for _, rule := range rules.Rules {
+			checkNode(rule, node, depth, report)
+		}
+		for _, child := range node.Children {
+			visit(child, depth+1)
+		}
+	}
+	visit(tree, 0)
+
+	sort.SliceStable(violations, func(i, j int) bool { return violations[i].Path < violations[j].Path })
+	return violations
+}
+
+// checkNode applies the checks of a rule to one node, at the given depth
+// below the root.
+func checkNode(rule *Rule, node *Node, depth int, report func(*Rule, string, string)) {
+	p := node.Path
+	root := depth == 0
+
+	if node.IsDir && matchAny(rule.Dirs, p, true) {
+		for _, required := range rule.Require {
+			found := false
+			for _, child := range node.Children {
+				if ok, _ := path.Match(required, child.Name); ok {
+					found = true
+					break
+				}
+			}
+			if !found {
+				report(rule, p, fmt.Sprintf("missing %s", required))
+			}
+		}
+		if len(rule.AllowedExtensions) > 0 {
+			for _, child := range node.Children {
+				if !child.IsDir && !hasExtension(child.Name, rule.AllowedExtensions) {
+					report(rule, child.Path, fmt.Sprintf("extension not allowed here (allowed: %s)", strings.Join(rule.AllowedExtensions, ", ")))
+				}
+			}
+		}
+	}
+	if root {
+		return
+	}
+
+	if matchAny(rule.Forbid, p, false) {
+		report(rule, p, "forbidden path")
+	}
+	if !matchAny(rule.Paths, p, true) {
+		return
+	}
+	if rule.namePattern != nil && !rule.namePattern.MatchString(node.Name) {
+		report(rule, p, fmt.Sprintf("name does not match %s", rule.NamePattern))
+	}
+	if rule.maxSize > 0 && !node.IsDir && node.info != nil && node.info.Size() > rule.maxSize {
+		report(rule, p, fmt.Sprintf("size %s exceeds %s", humanSize(node.info.Size()), rule.MaxSize))
+	}
+	// Only the first level that is too deep is reported, not its contents
+	if rule.MaxDepth > 0 && depth == rule.MaxDepth+1 {
+		report(rule, p, fmt.Sprintf("deeper than %d levels", rule.MaxDepth))
+	}
+}
+
+// matchAny reports whether p matches one of the patterns; an empty list
+// matches when emptyMatches is set.
+func matchAny(patterns []string, p string, emptyMatches bool) bool {
+	if len(patterns) == 0 {
+		return emptyMatches
+	}
+	for _, pattern := range patterns {
+		if matchPath(pattern, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPath matches a slash-separated path relative to the root. Patterns
+// without a slash match the last element; otherwise the pattern is matched
+// element by element and ** matches any number of elements.
+func matchPath(pattern, p string) bool {
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(p))
+		return ok
+	}
+
+	var match func(pattern, parts []string) bool
+	match = func(pattern, parts []string) bool {
+		for len(pattern) > 0 {
+			if pattern[0] == "**" {
+				for i := 0; i <= len(parts); i++ {
+					if match(pattern[1:], parts[i:]) {
+						return true
+					}
+				}
+				return false
+			}
+			if len(parts) == 0 {
+				return false
+			}
+			if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+				return false
+			}
+			pattern, parts = pattern[1:], parts[1:]
+		}
+		return len(parts) == 0
+	}
+	return match(strings.Split(strings.Trim(pattern, "/"), "/"), strings.Split(p, "/"))
+}
+
+// hasExtension reports whether name ends in one of the extensions.
+func hasExtension(name string, extensions []string) bool {
+	for _, ext := range extensions {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseSize parses a size such as 1500, 512K, 10M or 1.5GB, with binary
+// units.
+func parseSize(s string) (int64, error) {
+	text := strings.TrimSuffix(strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(s)), "B"), "I")
+	multiplier := int64(1)
+	if n := len(text); n > 0 {
+		if i := strings.IndexByte("KMGTPE", text[n-1]); i >= 0 {
+			multiplier = 1 << (10 * (i + 1))
+			text = text[:n-1]
+		}
+	}
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return int64(value * float64(multiplier)), nil
+}
+
+// WriteViolations prints violations as "text", "json" or "sarif".
+func WriteViolations(w io.Writer, violations []Violation, rules *RuleSet, format string) error {
+	counts := map[string]int{}
+	for _, v := range violations {
+		counts[v.Severity]++
+	}
+
+	switch format {
+	case "text":
+		for _, v := range violations {
+			fmt.Fprintf(w, "%-7s %s: %s [%s]\n", v.Severity, v.Path, v.Message, v.Rule)
+		}
+		fmt.Fprintf(w, "\n%d errors, %d warnings, %d notes\n", counts["error"], counts["warning"], counts["note"])
+		return nil
+	case "json":
+		data, _ := json.MarshalIndent(struct {
+			Violations []Violation    `json:"violations"`
+			Counts     map[string]int `json:"counts"`
+		}{append([]Violation{}, violations...), map[string]int{"error": counts["error"], "warning": counts["warning"], "note": counts["note"]}}, "", "  ")
+		_, err := fmt.Fprintln(w, string(data))
+		return err
+	case "sarif":
+		data, _ := json.MarshalIndent(sarifLog(violations, rules), "", "  ")
+		_, err := fmt.Fprintln(w, string(data))
+		return err
+	}
+	return fmt.Errorf("unknown format %q (available: text, json, sarif)", format)
+}
+
+// sarifLog builds a SARIF 2.1.0 log, the format read by code scanning tools.
+func sarifLog(violations []Violation, rules *RuleSet) map[string]any {
+	descriptors := []map[string]any{}
+	for _, rule := range rules.Rules {
+		descriptor := map[string]any{
+			"id":                   rule.ID,
+			"defaultConfiguration": map[string]any{"level": rule.Severity},
+		}
+		if rule.Message != "" {
+			descriptor["shortDescription"] = map[string]any{"text": rule.Message}
+		}
+		descriptors = append(descriptors, descriptor)
+	}
+
+	results := []map[string]any{}
+	for _, v := range violations {
+		results = append(results, map[string]any{
+			"ruleId":  v.Rule,
+			"level":   v.Severity,
+			"message": map[string]any{"text": v.Message},
+			"locations": []map[string]any{{
+				"physicalLocation": map[string]any{
+					"artifactLocation": map[string]any{"uri": v.Path},
+				},
+			}},
+		})
+	}
+
+	return map[string]any{
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"version": "2.1.0",
+		"runs": []map[string]any{{
+			"tool": map[string]any{
+				"driver": map[string]any{"name": "PrintLayout", "rules": descriptors},
+			},
+			"results": results,
+		}},
+	}
+}
@@ -0,0 +1,144 @@
+package printer
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// TestLint tests every kind of check against the test project.
+func TestLint(t *testing.T) {
+	rulesFile := filepath.Join(t.TempDir(), "layout-rules.yaml")
+	os.WriteFile(rulesFile, []byte(`exclude: [.git]
+rules:
+  - id: readme-per-package
+    dirs: ["pkg/*", "internal/*"]
+    require: [README.md]
+  - id: no-keys
+    forbid: ["*.pem"]
+  - id: cmd-go-only
+    severity: warning
+    dirs: [cmd]
+    allowed_extensions: [.go]
+  - id: shallow
+    severity: note
+    paths: ["pkg/**"]
+    max_depth: 2
+  - id: snake-case
+    paths: ["**/*.go"]
+    name_pattern: '^[a-z0-9_]+\.go$'
+  - id: small
+    max_size: 1K
+    message: keep large files out of the repository
+`), 0644)
+
+	fsys := testProjectFS()
+	fsys["internal/utils/README.md"] = &fstest.MapFile{}
+	fsys["cmd/notes.txt"] = &fstest.MapFile{}
+	fsys["cmd/BadName.go"] = &fstest.MapFile{}
+	fsys["deploy/key.pem"] = &fstest.MapFile{}
+	fsys["bin/big"] = &fstest.MapFile{Data: make([]byte, 2048)}
+
+	rules, err := LoadRules(rulesFile)
+	if err != nil {
+		t.Fatalf("LoadRules failed: %v", err)
+	}
+	violations, err := Lint(fsys, rules, Config{SortBy: "name", Order: "asc", IncludeHidden: true})
+	if err != nil {
+		t.Fatalf("Lint failed: %v", err)
+	}
+
+	var got strings.Builder
+	if err := WriteViolations(&got, violations, rules, "text"); err != nil {
+		t.Fatalf("WriteViolations failed: %v", err)
+	}
+	expected := "error   bin/big: keep large files out of the repository [small]\n" +
+		"error   cmd/BadName.go: name does not match ^[a-z0-9_]+\\.go$ [snake-case]\n" +
+		"warning cmd/notes.txt: extension not allowed here (allowed: .go) [cmd-go-only]\n" +
+		"error   deploy/key.pem: forbidden path [no-keys]\n" +
+		"error   pkg/printer: missing README.md [readme-per-package]\n" +
+		"note    pkg/printer/printer.go: deeper than 2 levels [shallow]\n" +
+		"note    pkg/printer/printer_test.go: deeper than 2 levels [shallow]\n" +
+		"\n4 errors, 1 warnings, 2 notes\n"
+	if got.String() != expected {
+		t.Errorf("Unexpected violations:\nGot:\n%s\nExpected:\n%s", got.String(), expected)
+	}
+
+	var sarif struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Results []struct {
+				RuleID string `json:"ruleId"`
+				Level  string `json:"level"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	var out strings.Builder
+	WriteViolations(&out, violations, rules, "sarif")
+	if err := json.Unmarshal([]byte(out.String()), &sarif); err != nil {
+		t.Fatalf("Invalid SARIF: %v", err)
+	}
+	if sarif.Version != "2.1.0" || len(sarif.Runs) != 1 || len(sarif.Runs[0].Results) != len(violations) {
+		t.Errorf("Unexpected SARIF log:\n%s", out.String())
+	} else if r := sarif.Runs[0].Results[2]; r.RuleID != "cmd-go-only" || r.Level != "warning" {
+		t.Errorf("Unexpected SARIF result %+v", r)
+	}
+
+	if err := WriteViolations(&out, violations, rules, "junit"); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
+
+// TestLoadRulesErrors tests that invalid rules are rejected up front.
+func TestLoadRulesErrors(t *testing.T) {
+	for rules, expected := range map[string]string{
+		"rules:\n  - severity: fatal\n":            `rule rule-1: unknown severity "fatal"`,
+		"rules:\n  - id: x\n    name_pattern: (\n": "rule x: error parsing regexp",
+		"rules:\n  - id: x\n    max_size: big\n":   `rule x: invalid size "big"`,
+		"rules:\n  - id: x\n    forbid: ['[']\n":   `rule x: bad pattern "["`,
+	} {
+		name := filepath.Join(t.TempDir(), "rules.yaml")
+		os.WriteFile(name, []byte(rules), 0644)
+		_, err := LoadRules(name)
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("LoadRules(%q) = %v, expected an error containing %q", rules, err, expected)
+		}
+	}
+}
+
+// TestMatchPath tests name patterns, rooted patterns and ** patterns.
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		pattern, path string
+		expected      bool
+	}{
+		{"*.pem", "deploy/keys/server.pem", true},
+		{"*.pem", "server.pem.txt", false},
+		{"pkg/*", "pkg/printer", true},
+		{"pkg/*", "pkg/printer/schema", false},
+		{"pkg/**", "pkg/printer/schema", true},
+		{"**/testdata/*.json", "a/b/testdata/x.json", true},
+		{"**/testdata/*.json", "testdata/x.json", true},
+		{"/cmd", "cmd", true},
+	}
+	for _, test := range tests {
+		if got := matchPath(test.pattern, test.path); got != test.expected {
+			t.Errorf("matchPath(%q, %q) = %v, expected %v", test.pattern, test.path, got, test.expected)
+		}
+	}
+}
+
+// TestParseSize tests plain and suffixed sizes.
+func TestParseSize(t *testing.T) {
+	for input, expected := range map[string]int64{"1500": 1500, "512K": 512 << 10, "10MB": 10 << 20, "1.5g": 3 << 29, "2KiB": 2048} {
+		if got, err := parseSize(input); err != nil || got != expected {
+			t.Errorf("parseSize(%q) = %d, %v, expected %d", input, got, err, expected)
+		}
+	}
+	if _, err := parseSize("-1"); err == nil {
+		t.Error("Expected an error for a negative size")
+	}
+}
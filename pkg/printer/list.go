@@ -0,0 +1,150 @@
+package printer
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"slices"
+	"strings"
+	"time"
+)
+
+// listFields are the columns available in the csv and tsv formats.
+var listFields = []string{"path", "type", "size", "mtime", "mode", "hash"}
+
+// defaultListFields are used when no --fields are given.
+var defaultListFields = []string{"path", "type", "size", "mtime"}
+
+// getListOutput renders the tree as a flat list in depth-first order, using
+// the same filters and sorting as the tree formats. The root itself is not
+// listed. "list" prints one path per line; "csv" and "tsv" print a header
+// and the selected fields.
+func getListOutput(ctx context.Context, tree *Node, config Config) (string, error) {
+	var nodes []*Node
+	var collect func(*Node)
+	collect = func(node *Node) {
+		for _, child := range node.Children {
+			nodes = append(nodes, child)
+			collect(child)
+		}
+	}
+	collect(tree)
+
+	if config.OutputFormat == "list" {
+		terminator := "\n"
+		if config.NullTerminated {
+			terminator = "\x00"
+		}
+		var sb strings.Builder
+		for _, node := range nodes {
+			sb.WriteString(node.Path + terminator)
+		}
+		return sb.String(), nil
+	}
+
+	fields := config.Fields
+	if len(fields) == 0 {
+		fields = defaultListFields
+	}
+	if err := checkListFields(fields); err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if config.OutputFormat == "tsv" {
+		w.Comma = '\t'
+	}
+	w.Write(fields)
+	for _, node := range nodes {
+		record := make([]string, len(fields))
+		for i, field := range fields {
+			record[i] = listField(ctx, node, field)
+		}
+		w.Write(record)
+	}
+	w.Flush()
+	return buf.String(), w.Error()
+}
+
+// checkListFields reports fields that are not in listFields.
+func checkListFields(fields []string) error {
+	for _, field := range fields {
+		if !slices.Contains(listFields, field) {
+			return fmt.Errorf("unknown field %q (available: %s)", field, strings.Join(listFields, ", "))
+		}
+	}
+	return nil
+}
+
+// listField returns the value of a csv/tsv field for a node. Metadata that is
+// not known is left empty.
+func listField(ctx context.Context, node *Node, field string) string {
+	switch field {
+	case "path":
+		return node.Path
+	case "type":
+		return nodeType(node)
+	case "hash":
+		return fileHash(ctx, node)
+	}
+
+	if node.info == nil {
+		return ""
+	}
+	switch field {
+	case "size":
+		return fmt.Sprint(node.info.Size())
+	case "mtime":
+		if node.info.ModTime().IsZero() {
+			return ""
+		}
+		return node.info.ModTime().Format(time.RFC3339)
+	case "mode":
+		return permString(node.info.Mode(), node.IsDir)
+	}
+	return ""
+}
+
+// nodeType returns "dir", "file", "symlink" or "other".
+func nodeType(node *Node) string {
+	switch {
+	case node.IsDir:
+		return "dir"
+	case node.info == nil && node.LinkTarget != "":
+		return "symlink"
+	case node.info == nil || node.info.Mode().IsRegular():
+		return "file"
+	case node.info.Mode()&fs.ModeSymlink != 0:
+		return "symlink"
+	}
+	return "other"
+}
+
+// fileHash returns the hex SHA-256 of a regular file's contents, or "" for
+// other entries, files that cannot be read, such as archive members, and
+// files still being read when ctx is done.
+func fileHash(ctx context.Context, node *Node) string {
+	if node.IsDir || node.fsys == nil || node.info == nil || !node.info.Mode().IsRegular() {
+		return ""
+	}
+	hash, _ := interruptible(ctx, func() (string, error) {
+		f, err := node.fsys.Open(node.fsPath)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+
+		h := sha256.New()
+		if _, err := io.Copy(h, f); err != nil {
+			return "", err
+		}
+		return hex.EncodeToString(h.Sum(nil)), nil
+	})
+	return hash
+}
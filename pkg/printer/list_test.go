@@ -0,0 +1,82 @@
+package printer
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// TestListFormats tests the flat list, csv and tsv output formats.
+func TestListFormats(t *testing.T) {
+	modTime := time.Date(2025, 1, 2, 15, 4, 0, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"src":         {Mode: fs.ModeDir | 0755, ModTime: modTime},
+		"src/main.go": {Mode: 0644, Data: []byte("package main\n"), ModTime: modTime},
+		"a, b.txt":    {Mode: 0600, Data: []byte("hi"), ModTime: modTime},
+		"link":        {Mode: fs.ModeSymlink | 0777, Data: []byte("src")},
+	}
+	config := Config{SortBy: "name", Order: "asc", MaxDepth: -1}
+
+	t.Run("List", func(t *testing.T) {
+		config := config
+		config.OutputFormat = "list"
+		output := captureOutput(func() { PrintFS(fsys, config) })
+
+		expected := "a, b.txt\nlink\nsrc\nsrc/main.go\n"
+		if output != expected {
+			t.Errorf("Unexpected output:\nGot:\n%q\nExpected:\n%q", output, expected)
+		}
+
+		config.NullTerminated = true
+		config.ExtFilter = ".go"
+		output = captureOutput(func() { PrintFS(fsys, config) })
+
+		expected = "src\x00src/main.go\x00"
+		if output != expected {
+			t.Errorf("Unexpected output:\nGot:\n%q\nExpected:\n%q", output, expected)
+		}
+	})
+
+	t.Run("CSV", func(t *testing.T) {
+		config := config
+		config.OutputFormat = "csv"
+		output := captureOutput(func() { PrintFS(fsys, config) })
+
+		expected := "path,type,size,mtime\n" +
+			"\"a, b.txt\",file,2,2025-01-02T15:04:00Z\n" +
+			"link,symlink,3,\n" +
+			"src,dir,0,2025-01-02T15:04:00Z\n" +
+			"src/main.go,file,13,2025-01-02T15:04:00Z\n"
+		if output != expected {
+			t.Errorf("Unexpected output:\nGot:\n%s\nExpected:\n%s", output, expected)
+		}
+	})
+
+	t.Run("TSVFields", func(t *testing.T) {
+		config := config
+		config.OutputFormat = "tsv"
+		config.DirPath = "src"
+		config.Fields = []string{"path", "mode", "hash"}
+		output := captureOutput(func() { PrintFS(fsys, config) })
+
+		// Paths are relative to the listed directory
+		expected := "path\tmode\thash\n" +
+			"main.go\t-rw-r--r--\tdf1d036cbbf3df46e2045071e082245ece204c7f53ecf0a4e022bff9bb228f47\n"
+		if output != expected {
+			t.Errorf("Unexpected output:\nGot:\n%q\nExpected:\n%q", output, expected)
+		}
+	})
+
+	t.Run("UnknownField", func(t *testing.T) {
+		config := config
+		config.OutputFormat = "csv"
+		config.Fields = []string{"path", "inode"}
+		output := captureOutput(func() { PrintFS(fsys, config) })
+
+		if !strings.HasPrefix(output, "Error: unknown field \"inode\"") {
+			t.Errorf("Expected an unknown field error, got:\n%s", output)
+		}
+	})
+}
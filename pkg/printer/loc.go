@@ -0,0 +1,246 @@
+package printer
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// LineCounts are the lines of a source file, or the sums of the source files
+// below a directory. Comment-only lines count as comments; lines with both
+// code and a comment count as code. String literals are not parsed, so
+// comment markers inside strings are taken at face value.
+type LineCounts struct {
+	Language string `json:"language,omitempty" yaml:"language,omitempty" xml:"language,attr,omitempty"` // files only
+	Lines    int    `json:"lines" yaml:"lines" xml:"lines,attr"`
+	Code     int    `json:"code" yaml:"code" xml:"code,attr"`
+	Comment  int    `json:"comment" yaml:"comment" xml:"comment,attr"`
+	Blank    int    `json:"blank" yaml:"blank" xml:"blank,attr"`
+}
+
+// add adds the counts of other, without the language.
+func (c *LineCounts) add(other *LineCounts) {
+	c.Lines += other.Lines
+	c.Code += other.Code
+	c.Comment += other.Comment
+	c.Blank += other.Blank
+}
+
+// String formats the counts as printed next to the tree entries.
+func (c *LineCounts) String() string {
+	counts := fmt.Sprintf("%d lines, %d code, %d comment, %d blank", c.Lines, c.Code, c.Comment, c.Blank)
+	if c.Language != "" {
+		return c.Language + ": " + counts
+	}
+	return counts
+}
+
+// language is the comment syntax of a source language.
+type language struct {
+	name  string
+	line  []string    // line comment markers
+	block [][2]string // block comment start and end markers
+}
+
+var (
+	cStyle    = language{line: []string{"//"}, block: [][2]string{{"/*", "*/"}}}
+	hashStyle = language{line: []string{"#"}}
+)
+
+// named returns a copy of style for the language called name.
+func (l language) named(name string) *language {
+	l.name = name
+	return &l
+}
+
+// languagesByExt maps lowercase extensions to languages.
+var languagesByExt = map[string]*language{
+	".go":    cStyle.named("Go"),
+	".c":     cStyle.named("C"),
+	".h":     cStyle.named("C"),
+	".cc":    cStyle.named("C++"),
+	".cpp":   cStyle.named("C++"),
+	".cxx":   cStyle.named("C++"),
+	".hpp":   cStyle.named("C++"),
+	".cs":    cStyle.named("C#"),
+	".java":  cStyle.named("Java"),
+	".kt":    cStyle.named("Kotlin"),
+	".kts":   cStyle.named("Kotlin"),
+	".scala": cStyle.named("Scala"),
+	".swift": cStyle.named("Swift"),
+	".rs":    cStyle.named("Rust"),
+	".js":    cStyle.named("JavaScript"),
+	".jsx":   cStyle.named("JavaScript"),
+	".mjs":   cStyle.named("JavaScript"),
+	".cjs":   cStyle.named("JavaScript"),
+	".ts":    cStyle.named("TypeScript"),
+	".tsx":   cStyle.named("TypeScript"),
+	".dart":  cStyle.named("Dart"),
+	".proto": cStyle.named("Protocol Buffers"),
+	".scss":  cStyle.named("SCSS"),
+	".php":   {name: "PHP", line: []string{"//", "#"}, block: [][2]string{{"/*", "*/"}}},
+	".css":   {name: "CSS", block: [][2]string{{"/*", "*/"}}},
+	".py":    hashStyle.named("Python"),
+	".rb":    hashStyle.named("Ruby"),
+	".pl":    hashStyle.named("Perl"),
+	".pm":    hashStyle.named("Perl"),
+	".r":     hashStyle.named("R"),
+	".sh":    hashStyle.named("Shell"),
+	".bash":  hashStyle.named("Shell"),
+	".zsh":   hashStyle.named("Shell"),
+	".ps1":   {name: "PowerShell", line: []string{"#"}, block: [][2]string{{"<#", "#>"}}},
+	".yaml":  hashStyle.named("YAML"),
+	".yml":   hashStyle.named("YAML"),
+	".toml":  hashStyle.named("TOML"),
+	".mk":    hashStyle.named("Makefile"),
+	".tf":    {name: "Terraform", line: []string{"#", "//"}, block: [][2]string{{"/*", "*/"}}},
+	".sql":   {name: "SQL", line: []string{"--"}, block: [][2]string{{"/*", "*/"}}},
+	".lua":   {name: "Lua", line: []string{"--"}, block: [][2]string{{"--[[", "]]"}}},
+	".hs":    {name: "Haskell", line: []string{"--"}, block: [][2]string{{"{-", "-}"}}},
+	".html":  {name: "HTML", block: [][2]string{{"<!--", "-->"}}},
+	".htm":   {name: "HTML", block: [][2]string{{"<!--", "-->"}}},
+	".xml":   {name: "XML", block: [][2]string{{"<!--", "-->"}}},
+	".md":    {name: "Markdown", block: [][2]string{{"<!--", "-->"}}},
+}
+
+// languagesByName maps file names without a telling extension to languages.
+var languagesByName = map[string]*language{
+	"Makefile":   hashStyle.named("Makefile"),
+	"makefile":   hashStyle.named("Makefile"),
+	"Dockerfile": hashStyle.named("Dockerfile"),
+}
+
+// languagesByInterpreter maps the interpreter of a shebang line, without
+// version digits, to languages.
+var languagesByInterpreter = map[string]*language{
+	"sh":      languagesByExt[".sh"],
+	"bash":    languagesByExt[".sh"],
+	"zsh":     languagesByExt[".sh"],
+	"dash":    languagesByExt[".sh"],
+	"ksh":     languagesByExt[".sh"],
+	"python":  languagesByExt[".py"],
+	"ruby":    languagesByExt[".rb"],
+	"perl":    languagesByExt[".pl"],
+	"node":    languagesByExt[".js"],
+	"Rscript": languagesByExt[".r"],
+	"pwsh":    languagesByExt[".ps1"],
+}
+
+// detectLanguage returns the language of a file from its name or, failing
+// that, from the shebang at the start of its contents; nil if unknown.
+func detectLanguage(name string, head []byte) *language {
+	if lang, ok := languagesByExt[strings.ToLower(path.Ext(name))]; ok {
+		return lang
+	}
+	if lang, ok := languagesByName[name]; ok {
+		return lang
+	}
+
+	line, ok := bytes.CutPrefix(head, []byte("#!"))
+	if !ok {
+		return nil
+	}
+	if i := bytes.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+	fields := strings.Fields(string(line))
+	if len(fields) == 0 {
+		return nil
+	}
+	// #!/usr/bin/env -S python3 -u names the interpreter after env's flags
+	interpreter := path.Base(fields[0])
+	if interpreter == "env" {
+		interpreter = ""
+		for _, field := range fields[1:] {
+			if !strings.HasPrefix(field, "-") {
+				interpreter = field
+				break
+			}
+		}
+	}
+	return languagesByInterpreter[strings.TrimRight(interpreter, "0123456789.")]
+}
+
+// countFileLines counts the lines of a regular file in fsys, returning nil
+// for files that are not recognized source files or cannot be read.
+func countFileLines(fsys fs.FS, name string) *LineCounts {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	head, _ := reader.Peek(512)
+	lang := detectLanguage(path.Base(name), head)
+	if lang == nil || bytes.IndexByte(head, 0) >= 0 {
+		return nil
+	}
+	counts, err := countLines(reader, lang)
+	if err != nil {
+		return nil
+	}
+	return counts
+}
+
+// countLines classifies every line of r as blank, comment or code.
+func countLines(r io.Reader, lang *language) (*LineCounts, error) {
+	counts := &LineCounts{Language: lang.name}
+	var blockEnd string // end marker of the open block comment, if any
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		counts.Lines++
+		if strings.TrimSpace(line) == "" {
+			counts.Blank++
+			continue
+		}
+
+		code := false
+	scan:
+		for i := 0; i < len(line); {
+			if blockEnd != "" {
+				end := strings.Index(line[i:], blockEnd)
+				if end < 0 {
+					break
+				}
+				i += end + len(blockEnd)
+				blockEnd = ""
+				continue
+			}
+			if line[i] == ' ' || line[i] == '\t' {
+				i++
+				continue
+			}
+			rest := line[i:]
+			// Block markers first: Lua's --[[ starts with its line marker
+			for _, block := range lang.block {
+				if strings.HasPrefix(rest, block[0]) {
+					i += len(block[0])
+					blockEnd = block[1]
+					continue scan
+				}
+			}
+			for _, marker := range lang.line {
+				if strings.HasPrefix(rest, marker) {
+					break scan
+				}
+			}
+			code = true
+			i++
+		}
+
+		if code {
+			counts.Code++
+		} else {
+			counts.Comment++
+		}
+	}
+	return counts, scanner.Err()
+}
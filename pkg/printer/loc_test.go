@@ -0,0 +1,83 @@
+package printer
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// TestCountLines tests line classification for each kind of comment syntax.
+func TestCountLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		expected LineCounts
+	}{
+		{"main.go", "package main\n\n// Comment\n/* Block\n   comment */\nfunc main() {} // trailing\n/* a */ x := 1\n\t\n", LineCounts{Language: "Go", Lines: 8, Code: 3, Comment: 3, Blank: 2}},
+		{"tool.py", "#!/usr/bin/env python3\nimport os\n  # indented\n\nprint('#')\n", LineCounts{Language: "Python", Lines: 5, Code: 2, Comment: 2, Blank: 1}},
+		{"init.lua", "--[[ block\nstill ]] local x = 1\n-- line\nprint(x)\n", LineCounts{Language: "Lua", Lines: 4, Code: 2, Comment: 2}},
+		{"page.html", "<!-- header -->\n<p>Hi</p>\n", LineCounts{Language: "HTML", Lines: 2, Code: 1, Comment: 1}},
+	}
+	for _, test := range tests {
+		lang := detectLanguage(test.name, []byte(test.source))
+		if lang == nil {
+			t.Errorf("%s: language not detected", test.name)
+			continue
+		}
+		counts, err := countLines(strings.NewReader(test.source), lang)
+		if err != nil {
+			t.Fatalf("%s: countLines failed: %v", test.name, err)
+		}
+		if *counts != test.expected {
+			t.Errorf("%s: got %+v, expected %+v", test.name, *counts, test.expected)
+		}
+	}
+}
+
+// TestDetectLanguage tests detection by extension, file name and shebang.
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		name, head, expected string
+	}{
+		{"App.JAVA", "", "Java"},
+		{"Makefile", "", "Makefile"},
+		{"deploy", "#!/bin/bash\nset -e\n", "Shell"},
+		{"run", "#!/usr/bin/env -S python3.12 -u\n", "Python"},
+		{"serve", "#!/usr/bin/env node\n", "JavaScript"},
+		{"notes", "just text\n", ""},
+		{"data.bin", "", ""},
+	}
+	for _, test := range tests {
+		name := ""
+		if lang := detectLanguage(test.name, []byte(test.head)); lang != nil {
+			name = lang.name
+		}
+		if name != test.expected {
+			t.Errorf("detectLanguage(%q, %q) = %q, expected %q", test.name, test.head, name, test.expected)
+		}
+	}
+}
+
+// TestLOCOutput tests that counts are printed next to files and summed per
+// directory, and that other files are skipped.
+func TestLOCOutput(t *testing.T) {
+	fsys := fstest.MapFS{
+		"cmd/main.go":     {Data: []byte("package main\n\n// Entry point\nfunc main() {}\n")},
+		"scripts/release": {Data: []byte("#!/bin/sh\necho done\n"), Mode: 0755},
+		"logo.png":        {Data: []byte("\x89PNG\x00\x00")},
+	}
+	config := Config{NoColor: true, OutputFormat: "text", SortBy: "name", Order: "asc", MaxDepth: -1, LOC: true}
+	output := captureOutput(func() { PrintFS(fsys, config) })
+
+	expected := "./ [6 lines, 3 code, 2 comment, 1 blank]\n" +
+		"├── cmd/ [4 lines, 2 code, 1 comment, 1 blank]\n" +
+		"│   └── main.go [Go: 4 lines, 2 code, 1 comment, 1 blank]\n" +
+		"├── logo.png\n" +
+		"└── scripts/ [2 lines, 1 code, 1 comment, 0 blank]\n" +
+		"    └── release [Shell: 2 lines, 1 code, 1 comment, 0 blank]\n" +
+		"\n" +
+		"2 directories, 3 files\n"
+	if output != expected {
+		t.Errorf("Unexpected output:\nGot:\n%s\nExpected:\n%s", output, expected)
+	}
+}
@@ -0,0 +1,304 @@
+package printer
+
+import (
+	"fmt"
+	"io/fs"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-runewidth"
+	langtag "golang.org/x/text/language"
+)
+
+// longColumns are the columns available in the long listing, in their
+// default order.
+var longColumns = []string{"perms", "owner", "group", "size", "time"}
+
+// timeStyles are the formats of the time column.
+var timeStyles = []string{"iso", "relative", "locale", "ls"}
+
+// cTimeLayout is the time layout of the C locale, also used for languages
+// missing from localeTimeLayouts.
+const cTimeLayout = "01/02/06 15:04"
+
+// localeTimeLayouts are the date and time layouts of the locale time style
+// by language, as date +"%x %R" prints them. English is handled by region.
+var localeTimeLayouts = map[string]string{
+	"de": "02.01.2006 15:04",
+	"es": "02/01/2006 15:04",
+	"fi": "02.01.2006 15:04",
+	"fr": "02/01/2006 15:04",
+	"it": "02/01/2006 15:04",
+	"ja": "2006/01/02 15:04",
+	"ko": "2006. 01. 02. 15:04",
+	"nl": "02-01-2006 15:04",
+	"pl": "02.01.2006 15:04",
+	"pt": "02/01/2006 15:04",
+	"ru": "02.01.2006 15:04",
+	"sv": "2006-01-02 15:04",
+	"zh": "2006/01/02 15:04",
+}
+
+// now returns the current time; tests replace it to get stable relative
+// times.
+var now = time.Now
+
+// longFormatter renders the aligned columns shown left of the tree with
+// --long.
+type longFormatter struct {
+	columns    []string
+	timeStyle  string
+	timeLayout string // layout of the locale time style
+	human      bool
+	widths     map[string]int
+}
+
+// newLongFormatter validates the column and time style settings and sizes
+// every column to the widest value in the tree.
+func newLongFormatter(tree *Node, config Config) (*longFormatter, error) {
+	f := &longFormatter{
+		columns:   config.Columns,
+		timeStyle: config.TimeStyle,
+		human:     config.HumanSizes,
+		widths:    map[string]int{},
+	}
+	if len(f.columns) == 0 {
+		f.columns = longColumns
+	}
+	for _, column := range f.columns {
+		if !slices.Contains(longColumns, column) {
+			return nil, fmt.Errorf("unknown column %q (available: %s)", column, strings.Join(longColumns, ", "))
+		}
+	}
+	if err := checkTimeStyle(f.timeStyle); err != nil {
+		return nil, err
+	}
+	switch f.timeStyle {
+	case "":
+		f.timeStyle = "iso"
+	case "locale":
+		f.timeLayout = localeTimeLayout()
+	}
+
+	var measure func(*Node)
+	measure = func(node *Node) {
+		for _, column := range f.columns {
+			f.widths[column] = max(f.widths[column], runewidth.StringWidth(f.value(node, column)))
+		}
+		for _, child := range node.Children {
+			measure(child)
+		}
+	}
+	measure(tree)
+	return f, nil
+}
+
+// format returns the padded columns for a node, followed by a separator.
+// Values are padded before they are colored so escape codes do not break
+// the alignment.
+func (f *longFormatter) format(node *Node, colors *palette) string {
+	var sb strings.Builder
+	for _, column := range f.columns {
+		value := f.value(node, column)
+		padding := strings.Repeat(" ", f.widths[column]-runewidth.StringWidth(value))
+		switch column {
+		case "size":
+			sb.WriteString(padding + colors.size(value))
+		case "time":
+			sb.WriteString(colors.date(value) + padding)
+		default:
+			sb.WriteString(value + padding)
+		}
+		sb.WriteString("  ")
+	}
+	return sb.String()
+}
+
+// blank returns spaces as wide as the columns, for lines without an entry.
+func (f *longFormatter) blank() string {
+	width := 0
+	for _, column := range f.columns {
+		width += f.widths[column] + 2
+	}
+	return strings.Repeat(" ", width)
+}
+
+// value returns the unpadded value of a column, or "-" when the node has no
+// such metadata.
+func (f *longFormatter) value(node *Node, column string) string {
+	info := node.info
+	if info == nil {
+		return "-"
+	}
+
+	switch column {
+	case "perms":
+		return permString(info.Mode(), node.IsDir)
+	case "owner", "group":
+		owner, group := fileOwner(info)
+		if column == "group" {
+			owner = group
+		}
+		if owner == "" {
+			return "-"
+		}
+		return owner
+	case "size":
+		if f.human {
+			return humanSize(info.Size())
+		}
+		return fmt.Sprint(info.Size())
+	case "time":
+		return formatTime(info.ModTime(), f.timeStyle, f.timeLayout)
+	}
+	return ""
+}
+
+// permString formats a mode the way ls -l does, e.g. "drwxr-xr-x" or
+// "-rwsr-xr-x". isDir marks archives expanded with --into-archives, whose
+// mode is that of a regular file.
+func permString(mode fs.FileMode, isDir bool) string {
+	b := []byte("----------")
+	switch {
+	case mode.IsDir() || isDir:
+		b[0] = 'd'
+	case mode&fs.ModeSymlink != 0:
+		b[0] = 'l'
+	case mode&fs.ModeNamedPipe != 0:
+		b[0] = 'p'
+	case mode&fs.ModeSocket != 0:
+		b[0] = 's'
+	case mode&fs.ModeCharDevice != 0:
+		b[0] = 'c'
+	case mode&fs.ModeDevice != 0:
+		b[0] = 'b'
+	}
+
+	const rwx = "rwxrwxrwx"
+	for i := 0; i < 9; i++ {
+		if mode&(1<<uint(8-i)) != 0 {
+			b[i+1] = rwx[i]
+		}
+	}
+
+	special := func(i int, set bool, lower, upper byte) {
+		if !set {
+			return
+		}
+		if b[i] == 'x' {
+			b[i] = lower
+		} else {
+			b[i] = upper
+		}
+	}
+	special(3, mode&fs.ModeSetuid != 0, 's', 'S')
+	special(6, mode&fs.ModeSetgid != 0, 's', 'S')
+	special(9, mode&fs.ModeSticky != 0, 't', 'T')
+	return string(b)
+}
+
+// humanSize formats a byte count with a binary unit suffix, e.g. 4.0K.
+func humanSize(size int64) string {
+	const units = "KMGTPE"
+	if size < 1024 {
+		return fmt.Sprint(size)
+	}
+	value := float64(size)
+	i := -1
+	for value >= 1024 && i < len(units)-1 {
+		value /= 1024
+		i++
+	}
+	if value < 10 {
+		return fmt.Sprintf("%.1f%c", value, units[i])
+	}
+	return fmt.Sprintf("%.0f%c", value, units[i])
+}
+
+// checkTimeStyle reports an unknown --time-style; empty means iso.
+func checkTimeStyle(style string) error {
+	if style != "" && !slices.Contains(timeStyles, style) {
+		return fmt.Errorf("unknown time style %q (available: %s)", style, strings.Join(timeStyles, ", "))
+	}
+	return nil
+}
+
+// localeTimeLayout returns the layout of the locale time style for the
+// language of LC_ALL, LC_TIME or LANG.
+func localeTimeLayout() string {
+	value := localeEnv("LC_TIME")
+	if value == "" || value == "C" || value == "POSIX" {
+		return cTimeLayout
+	}
+	tag, err := langtag.Parse(value)
+	if err != nil {
+		return cTimeLayout
+	}
+	base, _ := tag.Base()
+	if base.String() == "en" {
+		// The region of a bare "en" is inferred as US
+		if region, _ := tag.Region(); region.String() == "US" {
+			return "01/02/2006 03:04 PM"
+		}
+		return "02/01/2006 15:04"
+	}
+	if layout, ok := localeTimeLayouts[base.String()]; ok {
+		return layout
+	}
+	return cTimeLayout
+}
+
+// formatTime formats a modification time in the given --time-style. layout
+// is the layout of the locale style.
+func formatTime(t time.Time, style, layout string) string {
+	if t.IsZero() {
+		return "-"
+	}
+
+	switch style {
+	case "relative":
+		return relativeTime(now().Sub(t))
+	case "locale":
+		return t.Local().Format(layout)
+	case "ls":
+		// The default format of ls: the time of day for recent files, the
+		// year for files older than six months or in the future. Month
+		// names are always English.
+		age := now().Sub(t)
+		if age < 0 || age > 182*24*time.Hour {
+			return t.Local().Format("Jan _2  2006")
+		}
+		return t.Local().Format("Jan _2 15:04")
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+// relativeTime describes an age such as "3 days ago".
+func relativeTime(age time.Duration) string {
+	suffix := "ago"
+	if age < 0 {
+		age, suffix = -age, "from now"
+	}
+
+	units := []struct {
+		name string
+		size time.Duration
+	}{
+		{"year", 365 * 24 * time.Hour},
+		{"month", 30 * 24 * time.Hour},
+		{"week", 7 * 24 * time.Hour},
+		{"day", 24 * time.Hour},
+		{"hour", time.Hour},
+		{"minute", time.Minute},
+	}
+	for _, unit := range units {
+		if n := int(age / unit.size); n >= 1 {
+			if n > 1 {
+				return fmt.Sprintf("%d %ss %s", n, unit.name, suffix)
+			}
+			return fmt.Sprintf("1 %s %s", unit.name, suffix)
+		}
+	}
+	return "just now"
+}
@@ -0,0 +1,169 @@
+package printer
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// TestLongListing tests the columns printed left of the tree with --long.
+func TestLongListing(t *testing.T) {
+	modTime := time.Date(2025, 1, 2, 15, 4, 0, 0, time.Local)
+	fsys := fstest.MapFS{
+		"bin":        {Mode: fs.ModeDir | 0755, ModTime: modTime},
+		"bin/tool":   {Mode: 0755, Data: make([]byte, 12345), ModTime: modTime},
+		"notes.txt":  {Mode: 0644, Data: []byte("hi"), ModTime: modTime.Add(-72 * time.Hour)},
+		"shared":     {Mode: fs.ModeDir | fs.ModeSticky | 0777, ModTime: modTime},
+		"shared/run": {Mode: fs.ModeSetuid | 0755, ModTime: modTime},
+	}
+	config := Config{NoColor: true, OutputFormat: "text", SortBy: "name", Order: "asc", MaxDepth: -1, Long: true}
+
+	t.Run("DefaultColumns", func(t *testing.T) {
+		output := captureOutput(func() { PrintFS(fsys, config) })
+
+		// The root of a MapFS is a synthesized directory without a time
+		expected := "dr-xr-xr-x  -  -      0  -                 ./\n" +
+			"drwxr-xr-x  -  -      0  2025-01-02 15:04  ├── bin/\n" +
+			"-rwxr-xr-x  -  -  12345  2025-01-02 15:04  │   └── tool\n" +
+			"-rw-r--r--  -  -      2  2024-12-30 15:04  ├── notes.txt\n" +
+			"drwxrwxrwt  -  -      0  2025-01-02 15:04  └── shared/\n" +
+			"-rwsr-xr-x  -  -      0  2025-01-02 15:04      └── run\n" +
+			"\n2 directories, 3 files\n"
+		if output != expected {
+			t.Errorf("Unexpected output:\nGot:\n%s\nExpected:\n%s", output, expected)
+		}
+	})
+
+	t.Run("SelectedColumns", func(t *testing.T) {
+		defer func(orig func() time.Time) { now = orig }(now)
+		now = func() time.Time { return modTime.Add(2 * time.Hour) }
+
+		config := config
+		config.DirPath = "bin"
+		config.Columns = []string{"size", "time"}
+		config.TimeStyle = "relative"
+		config.HumanSizes = true
+		output := captureOutput(func() { PrintFS(fsys, config) })
+
+		expected := "  0  2 hours ago  bin/\n" +
+			"12K  2 hours ago  └── tool\n" +
+			"\n0 directories, 1 files\n"
+		if output != expected {
+			t.Errorf("Unexpected output:\nGot:\n%s\nExpected:\n%s", output, expected)
+		}
+	})
+
+	t.Run("InvalidSettings", func(t *testing.T) {
+		for _, c := range []Config{
+			{Long: true, Columns: []string{"inode"}},
+			{Long: true, TimeStyle: "epoch"},
+		} {
+			if _, err := newTextOptions(&Node{}, c); err == nil {
+				t.Errorf("Expected an error for %+v", c)
+			}
+		}
+	})
+}
+
+// TestFormatTime tests the time styles of the long listing.
+func TestFormatTime(t *testing.T) {
+	defer func(orig func() time.Time, local *time.Location) { now, time.Local = orig, local }(now, time.Local)
+	time.Local = time.UTC
+	current := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	now = func() time.Time { return current }
+
+	for _, test := range []struct {
+		t        time.Time
+		style    string
+		expected string
+	}{
+		{current.Add(-2 * time.Hour), "iso", "2025-06-01 10:00"},
+		{current.Add(-2 * time.Hour), "ls", "Jun  1 10:00"},
+		{time.Date(2024, 1, 15, 8, 30, 0, 0, time.UTC), "ls", "Jan 15  2024"},
+		{current.Add(24 * time.Hour), "ls", "Jun  2  2025"},
+		{time.Time{}, "ls", "-"},
+	} {
+		if got := formatTime(test.t, test.style, ""); got != test.expected {
+			t.Errorf("formatTime(%v, %s) = %q, expected %q", test.t, test.style, got, test.expected)
+		}
+	}
+}
+
+// TestLocaleTimeStyle tests the locale time style in several locales.
+func TestLocaleTimeStyle(t *testing.T) {
+	defer func(local *time.Location) { time.Local = local }(time.Local)
+	time.Local = time.UTC
+	modTime := time.Date(2024, 3, 7, 14, 5, 0, 0, time.UTC)
+
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LANG", "")
+	for _, test := range []struct {
+		locale   string
+		expected string
+	}{
+		{"", "03/07/24 14:05"},
+		{"C.UTF-8", "03/07/24 14:05"},
+		{"en_US.UTF-8", "03/07/2024 02:05 PM"},
+		{"en_GB.UTF-8", "07/03/2024 14:05"},
+		{"de_DE.UTF-8@euro", "07.03.2024 14:05"},
+		{"ja_JP.UTF-8", "2024/03/07 14:05"},
+		{"sv_SE", "2024-03-07 14:05"},
+		{"tlh", "03/07/24 14:05"},
+	} {
+		t.Setenv("LC_TIME", test.locale)
+		if got := formatTime(modTime, "locale", localeTimeLayout()); got != test.expected {
+			t.Errorf("%q: got %q, expected %q", test.locale, got, test.expected)
+		}
+	}
+
+	// LC_ALL wins over LC_TIME
+	t.Setenv("LC_ALL", "fr_FR.UTF-8")
+	if got := localeTimeLayout(); got != "02/01/2006 15:04" {
+		t.Errorf("Expected the French layout, got %q", got)
+	}
+}
+
+// TestPermString tests the ls-style permission strings.
+func TestPermString(t *testing.T) {
+	tests := []struct {
+		mode     fs.FileMode
+		isDir    bool
+		expected string
+	}{
+		{0644, false, "-rw-r--r--"},
+		{fs.ModeDir | 0755, false, "drwxr-xr-x"},
+		{0644, true, "drw-r--r--"},
+		{fs.ModeSymlink | 0777, false, "lrwxrwxrwx"},
+		{fs.ModeNamedPipe | 0600, false, "prw-------"},
+		{fs.ModeSetgid | 0640, false, "-rw-r-S---"},
+		{fs.ModeDir | fs.ModeSticky | 0776, false, "drwxrwxrwT"},
+		{fs.ModeDevice | fs.ModeCharDevice | 0666, false, "crw-rw-rw-"},
+	}
+
+	for _, tt := range tests {
+		if got := permString(tt.mode, tt.isDir); got != tt.expected {
+			t.Errorf("permString(%v) = %s, expected %s", tt.mode, got, tt.expected)
+		}
+	}
+}
+
+// TestHumanSize tests the human-readable sizes.
+func TestHumanSize(t *testing.T) {
+	tests := map[int64]string{
+		0:                  "0",
+		1023:               "1023",
+		1024:               "1.0K",
+		1536:               "1.5K",
+		10 * 1024:          "10K",
+		5 * 1024 * 1024:    "5.0M",
+		3 << 40:            "3.0T",
+		123 * 1024 * 1024:  "123M",
+		1024 * 1024 * 1024: "1.0G",
+	}
+	for size, expected := range tests {
+		if got := humanSize(size); got != expected {
+			t.Errorf("humanSize(%d) = %s, expected %s", size, got, expected)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package printer
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// markdownEscaper escapes the characters that Markdown would otherwise
+// interpret in entry names.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+	"<", `\<`, ">", `\>`, "#", `\#`, "|", `\|`, "~", `\~`,
+)
+
+// getMarkdownOutput renders the tree as a nested Markdown list.
+func getMarkdownOutput(tree *Node) string {
+	var sb strings.Builder
+	var visit func(node *Node, indent string)
+	visit = func(node *Node, indent string) {
+		sb.WriteString(indent + "- " + markdownEscaper.Replace(entryLabel(node)) + "\n")
+		for _, child := range node.Children {
+			visit(child, indent+"  ")
+		}
+	}
+	visit(tree, "")
+	return sb.String()
+}
+
+// getHTMLOutput renders the tree as a self-contained HTML page in which
+// directories can be folded.
+func getHTMLOutput(tree *Node) string {
+	var sb strings.Builder
+	sb.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(&sb, "<title>%s</title>\n", html.EscapeString(tree.Name))
+	sb.WriteString("<style>body{font-family:sans-serif}ul{list-style:none;padding-left:1.2em}" +
+		"summary,.dir{font-weight:bold}.file{font-weight:normal}</style>\n")
+	sb.WriteString("</head>\n<body>\n<ul>\n")
+
+	var visit func(node *Node)
+	visit = func(node *Node) {
+		label := html.EscapeString(entryLabel(node))
+		if !node.IsDir {
+			fmt.Fprintf(&sb, "<li class=\"file\">%s</li>\n", label)
+			return
+		}
+		fmt.Fprintf(&sb, "<li class=\"dir\"><details open><summary>%s</summary>\n<ul>\n", label)
+		for _, child := range node.Children {
+			visit(child)
+		}
+		sb.WriteString("</ul>\n</details></li>\n")
+	}
+	visit(tree)
+
+	dirs, files := countTree(tree)
+	fmt.Fprintf(&sb, "</ul>\n<p>%d directories, %d files</p>\n</body>\n</html>\n", dirs, files)
+	return sb.String()
+}
+
+// entryLabel returns the name of a node as shown in the markup formats:
+// directories end in a slash and symlinks show their target.
+func entryLabel(node *Node) string {
+	label := node.Name
+	if node.IsDir {
+		label += "/"
+	}
+	if node.LinkTarget != "" {
+		label += " -> " + node.LinkTarget
+	}
+	return label
+}
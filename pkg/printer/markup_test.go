@@ -0,0 +1,50 @@
+package printer
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+// TestMarkupFormats tests the markdown and html formats.
+func TestMarkupFormats(t *testing.T) {
+	fsys := fstest.MapFS{
+		"docs/a_b.md": {},
+		"<x>.txt":     {},
+	}
+	config := Config{SortBy: "name", Order: "asc", MaxDepth: -1}
+
+	t.Run("Markdown", func(t *testing.T) {
+		config := config
+		config.OutputFormat = "markdown"
+		output := captureOutput(func() { PrintFS(fsys, config) })
+
+		expected := "- ./\n" +
+			"  - \\<x\\>.txt\n" +
+			"  - docs/\n" +
+			"    - a\\_b.md\n"
+		if output != expected {
+			t.Errorf("Unexpected output:\nGot:\n%s\nExpected:\n%s", output, expected)
+		}
+	})
+
+	t.Run("HTML", func(t *testing.T) {
+		config := config
+		config.OutputFormat = "html"
+		output := captureOutput(func() { PrintFS(fsys, config) })
+
+		expected := "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n" +
+			"<title>.</title>\n" +
+			"<style>body{font-family:sans-serif}ul{list-style:none;padding-left:1.2em}summary,.dir{font-weight:bold}.file{font-weight:normal}</style>\n" +
+			"</head>\n<body>\n<ul>\n" +
+			"<li class=\"dir\"><details open><summary>./</summary>\n<ul>\n" +
+			"<li class=\"file\">&lt;x&gt;.txt</li>\n" +
+			"<li class=\"dir\"><details open><summary>docs/</summary>\n<ul>\n" +
+			"<li class=\"file\">a_b.md</li>\n" +
+			"</ul>\n</details></li>\n" +
+			"</ul>\n</details></li>\n" +
+			"</ul>\n<p>1 directories, 2 files</p>\n</body>\n</html>\n"
+		if output != expected {
+			t.Errorf("Unexpected output:\nGot:\n%s\nExpected:\n%s", output, expected)
+		}
+	})
+}
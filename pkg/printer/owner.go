@@ -0,0 +1,15 @@
+package printer
+
+import (
+	"archive/tar"
+	"io/fs"
+)
+
+// fileOwner returns the owner and group names of a file, or empty strings
+// when the file system does not record them.
+func fileOwner(info fs.FileInfo) (string, string) {
+	if hdr, ok := info.Sys().(*tar.Header); ok {
+		return hdr.Uname, hdr.Gname
+	}
+	return sysOwner(info)
+}
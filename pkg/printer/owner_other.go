@@ -0,0 +1,10 @@
+//go:build !unix
+
+package printer
+
+import "io/fs"
+
+// sysOwner reports no owner on platforms without Unix ownership.
+func sysOwner(info fs.FileInfo) (string, string) {
+	return "", ""
+}
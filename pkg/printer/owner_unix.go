@@ -0,0 +1,49 @@
+//go:build unix
+
+package printer
+
+import (
+	"io/fs"
+	"os/user"
+	"strconv"
+	"sync"
+	"syscall"
+)
+
+var (
+	ownerMu    sync.Mutex
+	userNames  = map[uint32]string{}
+	groupNames = map[uint32]string{}
+)
+
+// sysOwner looks up the owner and group of a file on disk. Names are cached
+// since a tree usually has few distinct owners; ids without a name are
+// printed as numbers, like ls does.
+func sysOwner(info fs.FileInfo) (string, string) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "", ""
+	}
+
+	ownerMu.Lock()
+	defer ownerMu.Unlock()
+
+	uid, gid := uint32(st.Uid), uint32(st.Gid)
+	owner, ok := userNames[uid]
+	if !ok {
+		owner = strconv.FormatUint(uint64(uid), 10)
+		if u, err := user.LookupId(owner); err == nil {
+			owner = u.Username
+		}
+		userNames[uid] = owner
+	}
+	group, ok := groupNames[gid]
+	if !ok {
+		group = strconv.FormatUint(uint64(gid), 10)
+		if g, err := user.LookupGroupId(group); err == nil {
+			group = g.Name
+		}
+		groupNames[gid] = group
+	}
+	return owner, group
+}
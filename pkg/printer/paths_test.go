@@ -0,0 +1,98 @@
+package printer
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+// TestFullPath tests printing paths instead of names.
+func TestFullPath(t *testing.T) {
+	fsys := fstest.MapFS{
+		"cmd/main.go": {},
+		"go.mod":      {},
+	}
+	config := Config{NoColor: true, OutputFormat: "text", SortBy: "name", Order: "asc", MaxDepth: -1, FullPath: true}
+
+	output := captureOutput(func() { PrintFS(fsys, config) })
+
+	expected := "./\n" +
+		"├── cmd/\n" +
+		"│   └── cmd/main.go\n" +
+		"└── go.mod\n" +
+		"\n1 directories, 2 files\n"
+	if output != expected {
+		t.Errorf("Unexpected output:\nGot:\n%s\nExpected:\n%s", output, expected)
+	}
+}
+
+// TestNodePaths tests the path recorded on every node of the structured
+// output, relative and absolute.
+func TestNodePaths(t *testing.T) {
+	tmpDir := t.TempDir()
+	createTestProjectStructure(t, tmpDir)
+
+	config := Config{DirPath: tmpDir, OutputFormat: "json", SortBy: "name", Order: "asc", MaxDepth: -1}
+
+	collect := func(config Config) map[string]string {
+		output := captureOutput(func() { HandleFlags(config) })
+		var doc Document
+		if err := json.Unmarshal([]byte(output), &doc); err != nil {
+			t.Fatalf("Output is not valid JSON: %v", err)
+		}
+		paths := map[string]string{}
+		var visit func(*Entry)
+		visit = func(entry *Entry) {
+			paths[entry.Name] = entry.Path
+			for _, child := range entry.Children {
+				visit(child)
+			}
+		}
+		visit(doc.Tree)
+		return paths
+	}
+
+	t.Run("Relative", func(t *testing.T) {
+		paths := collect(config)
+		expected := map[string]string{
+			filepath.Base(tmpDir): ".",
+			"cmd":                 "cmd",
+			"main.go":             "cmd/main.go",
+			"utils.go":            "internal/utils/utils.go",
+			"printer_test.go":     "pkg/printer/printer_test.go",
+		}
+		for name, want := range expected {
+			if paths[name] != want {
+				t.Errorf("Path of %s is %q, expected %q", name, paths[name], want)
+			}
+		}
+	})
+
+	t.Run("Absolute", func(t *testing.T) {
+		config := config
+		config.AbsolutePaths = true
+		paths := collect(config)
+
+		root := filepath.ToSlash(tmpDir)
+		if paths[filepath.Base(tmpDir)] != root {
+			t.Errorf("Root path is %q, expected %q", paths[filepath.Base(tmpDir)], root)
+		}
+		if want := root + "/internal/utils/utils.go"; paths["utils.go"] != want {
+			t.Errorf("Path of utils.go is %q, expected %q", paths["utils.go"], want)
+		}
+	})
+
+	t.Run("AbsoluteText", func(t *testing.T) {
+		config := Config{DirPath: filepath.Join(tmpDir, "cmd"), NoColor: true, OutputFormat: "text", MaxDepth: -1, AbsolutePaths: true}
+		output := captureOutput(func() { HandleFlags(config) })
+
+		dir := filepath.ToSlash(filepath.Join(tmpDir, "cmd"))
+		expected := dir + "/\n" +
+			"└── " + dir + "/main.go\n" +
+			"\n0 directories, 1 files\n"
+		if output != expected {
+			t.Errorf("Unexpected output:\nGot:\n%s\nExpected:\n%s", output, expected)
+		}
+	})
+}
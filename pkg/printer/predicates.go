@@ -0,0 +1,182 @@
+package printer
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// predicates are the size, time and type filters of a Config, parsed once
+// per walk. All of them must match (AND). Files are shown when they match;
+// directories are shown when they contain a shown entry, or when they match
+// themselves and the predicates select directories (--type d or --empty).
+type predicates struct {
+	minSize, maxSize int64 // -1 when not set
+	newer, older     time.Time
+	types            string // letters of --type, empty for any type
+	empty            bool
+}
+
+// fileTypes are the letters accepted by --type.
+const fileTypes = "fdlx"
+
+// newPredicates parses the predicate filters of config, returning nil when
+// none is set.
+func newPredicates(config Config) (*predicates, error) {
+	if config.MinSize == "" && config.MaxSize == "" && config.NewerThan == "" &&
+		config.OlderThan == "" && config.Types == "" && !config.Empty {
+		return nil, nil
+	}
+
+	p := &predicates{minSize: -1, maxSize: -1, empty: config.Empty}
+	var err error
+	if config.MinSize != "" {
+		if p.minSize, err = parseSize(config.MinSize); err != nil {
+			return nil, fmt.Errorf("--min-size: %w", err)
+		}
+	}
+	if config.MaxSize != "" {
+		if p.maxSize, err = parseSize(config.MaxSize); err != nil {
+			return nil, fmt.Errorf("--max-size: %w", err)
+		}
+	}
+	if config.NewerThan != "" {
+		if p.newer, err = parseTimeBound(config.NewerThan); err != nil {
+			return nil, fmt.Errorf("--newer-than: %w", err)
+		}
+	}
+	if config.OlderThan != "" {
+		if p.older, err = parseTimeBound(config.OlderThan); err != nil {
+			return nil, fmt.Errorf("--older-than: %w", err)
+		}
+	}
+	for _, t := range strings.Split(config.Types, ",") {
+		t = strings.TrimSpace(t)
+		if t == "" {
+			continue
+		}
+		if len(t) != 1 || !strings.Contains(fileTypes, t) {
+			return nil, fmt.Errorf("--type: unknown type %q (available: f, d, l, x)", t)
+		}
+		p.types += t
+	}
+	return p, nil
+}
+
+// hasType reports whether info is of one of the selected types.
+func (p *predicates) hasType(info fs.FileInfo) bool {
+	if p.types == "" {
+		return true
+	}
+	mode := info.Mode()
+	switch {
+	case mode.IsDir():
+		return strings.Contains(p.types, "d")
+	case mode&fs.ModeSymlink != 0:
+		return strings.Contains(p.types, "l")
+	case mode.IsRegular():
+		return strings.Contains(p.types, "f") || (strings.Contains(p.types, "x") && isExecutable(info))
+	}
+	return false
+}
+
+// matchTime reports whether the modification time is within the bounds.
+func (p *predicates) matchTime(info fs.FileInfo) bool {
+	if !p.newer.IsZero() && !info.ModTime().After(p.newer) {
+		return false
+	}
+	return p.older.IsZero() || info.ModTime().Before(p.older)
+}
+
+// matchFile reports whether an entry that is not a directory is shown.
+func (p *predicates) matchFile(info fs.FileInfo) bool {
+	if p == nil {
+		return true
+	}
+	size := info.Size()
+	switch {
+	case !p.hasType(info), !p.matchTime(info):
+		return false
+	case p.minSize >= 0 && size < p.minSize, p.maxSize >= 0 && size > p.maxSize:
+		return false
+	case p.empty && (!info.Mode().IsRegular() || size != 0):
+		return false
+	}
+	return true
+}
+
+// keepDir reports whether a walked directory is shown. For --empty, the
+// directory must have no entries at all, including filtered ones.
+func (p *predicates) keepDir(ctx context.Context, node *Node) bool {
+	if p == nil || len(node.Children) > 0 {
+		return true
+	}
+	selectsDirs := strings.Contains(p.types, "d") || (p.types == "" && p.empty)
+	switch {
+	case !selectsDirs, node.info == nil:
+		return false
+	case p.minSize >= 0, p.maxSize >= 0:
+		return false // directories have no size of their own
+	case p.empty && !isEmptyDir(ctx, node):
+		return false
+	}
+	return p.hasType(node.info) && p.matchTime(node.info)
+}
+
+// isEmptyDir reports whether the directory of node has no entries. A
+// directory the walk did not read, such as one at the maximum depth, is read
+// here.
+func isEmptyDir(ctx context.Context, node *Node) bool {
+	if node.empty {
+		return true
+	}
+	if node.err != nil || node.fsys == nil {
+		return false
+	}
+	entries, err := interruptible(ctx, func() ([]fs.DirEntry, error) { return fs.ReadDir(node.fsys, node.fsPath) })
+	return err == nil && len(entries) == 0
+}
+
+// agePattern matches ages such as 90s, 15m, 12h, 2d, 3w or 1y.
+var agePattern = regexp.MustCompile(`^(\d+(?:\.\d+)?)([smhdwy])$`)
+
+// parseAge parses an age such as 2d; a year is 365 days.
+func parseAge(s string) (time.Duration, bool) {
+	m := agePattern.FindStringSubmatch(s)
+	if m == nil {
+		return 0, false
+	}
+	value, _ := strconv.ParseFloat(m[1], 64)
+	unit := map[string]time.Duration{
+		"s": time.Second,
+		"m": time.Minute,
+		"h": time.Hour,
+		"d": 24 * time.Hour,
+		"w": 7 * 24 * time.Hour,
+		"y": 365 * 24 * time.Hour,
+	}[m[2]]
+	return time.Duration(value * float64(unit)), true
+}
+
+// parseTimeBound parses an age relative to now, such as 2d, or a date or
+// time: 2025-01-01, 2025-01-01 15:04 or RFC 3339. Dates without a zone are
+// in local time.
+func parseTimeBound(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if age, ok := parseAge(s); ok {
+		return now().Add(-age), nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02", "2006-01-02 15:04", "2006-01-02T15:04"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid age or date %q (e.g. 2d, 12h, 2025-01-01)", s)
+}
@@ -0,0 +1,88 @@
+package printer
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// TestPredicates tests the size, time and type filters alone and combined.
+func TestPredicates(t *testing.T) {
+	defer func(orig func() time.Time) { now = orig }(now)
+	now = func() time.Time { return time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC) }
+	daysAgo := func(d int) time.Time { return now().AddDate(0, 0, -d) }
+
+	fsys := fstest.MapFS{
+		"logs/old.log":      {Data: make([]byte, 5000), ModTime: daysAgo(400)},
+		"logs/today.log":    {Data: make([]byte, 100), ModTime: daysAgo(0)},
+		"src/main.go":       {Data: make([]byte, 1200), ModTime: daysAgo(1)},
+		"src/.keep":         {ModTime: daysAgo(30)},
+		"src/gen":           {Mode: fs.ModeDir | 0755, ModTime: daysAgo(2)},
+		"bin/run":           {Data: make([]byte, 20), Mode: 0755, ModTime: daysAgo(10)},
+		"bin/latest":        {Data: []byte("run"), Mode: fs.ModeSymlink | 0777, ModTime: daysAgo(10)},
+		"tmp":               {Mode: fs.ModeDir | 0755, ModTime: daysAgo(90)},
+		"tmp/placeholder/x": {ModTime: daysAgo(90)},
+	}
+
+	tests := []struct {
+		name     string
+		config   Config
+		expected string
+	}{
+		{"MinSize", Config{MinSize: "1K"}, "logs/ logs/old.log src/ src/main.go"},
+		{"SizeRange", Config{MinSize: "50", MaxSize: "2K"}, "logs/ logs/today.log src/ src/main.go"},
+		{"BigAndUntouched", Config{MinSize: "1K", OlderThan: "1y"}, "logs/ logs/old.log"},
+		{"NewerThan", Config{NewerThan: "3d"}, "logs/ logs/today.log src/ src/main.go"},
+		{"OlderThanDate", Config{OlderThan: "2025-03-05"}, "logs/ logs/old.log tmp/ tmp/placeholder/ tmp/placeholder/x"},
+		{"Executables", Config{Types: "x"}, "bin/ bin/run"},
+		{"Symlinks", Config{Types: "l"}, "bin/ bin/latest"},
+		{"Directories", Config{Types: "d", NewerThan: "5d"}, "src/ src/gen/"},
+		{"Empty", Config{Empty: true}, "src/ src/.keep src/gen/ tmp/ tmp/placeholder/ tmp/placeholder/x"},
+		{"EmptyDirectories", Config{Empty: true, Types: "d"}, "src/ src/gen/"},
+	}
+	for _, test := range tests {
+		config := test.config
+		config.SortBy, config.Order, config.MaxDepth, config.IncludeHidden = "name", "asc", -1, true
+
+		tree, err := BuildTree(fsys, config)
+		if err != nil {
+			t.Errorf("%s: BuildTree failed: %v", test.name, err)
+			continue
+		}
+		var got []string
+		var visit func(node *Node)
+		visit = func(node *Node) {
+			for _, child := range node.Children {
+				if child.IsDir {
+					got = append(got, child.Path+"/")
+				} else {
+					got = append(got, child.Path)
+				}
+				visit(child)
+			}
+		}
+		visit(tree)
+		if strings.Join(got, " ") != test.expected {
+			t.Errorf("%s: got %q, expected %q", test.name, strings.Join(got, " "), test.expected)
+		}
+	}
+}
+
+// TestPredicateErrors tests that invalid values are reported by the walk.
+func TestPredicateErrors(t *testing.T) {
+	for _, test := range []struct {
+		config   Config
+		expected string
+	}{
+		{Config{MinSize: "lots"}, `--min-size: invalid size "lots"`},
+		{Config{NewerThan: "yesterday"}, `--newer-than: invalid age or date "yesterday"`},
+		{Config{Types: "f,z"}, `--type: unknown type "z"`},
+	} {
+		_, err := BuildTree(fstest.MapFS{"a": {}}, test.config)
+		if err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("BuildTree(%+v) = %v, expected an error containing %q", test.config, err, test.expected)
+		}
+	}
+}
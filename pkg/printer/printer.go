@@ -1,70 +1,291 @@
 package printer
 
 import (
+	"context"
 	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
-	"sort"
 	"strings"
+	"time"
 
-	"github.com/fatih/color"
 	"gopkg.in/yaml.v3"
 )
 
-// Config holds the flag values
+// Config holds the flag values. The yaml tags name the keys accepted in a
+// config file loaded with LoadConfig.
 type Config struct {
-	DirPath         string
-	OutputPath      string
-	ExtFilter       string
-	NoColor         bool
-	OutputFormat    string
-	DirColor        string
-	FileColor       string
-	ExecColor       string
-	ExcludePatterns []string
-	SortBy          string // "name", "size", "time"
-	Order           string // "asc", "desc"
-	IncludeHidden   bool
-	MaxDepth        int
+	DirPath         string     `yaml:"dir"`
+	OutputPath      string     `yaml:"output"`
+	ExtFilter       string     `yaml:"ext"`
+	NoColor         bool       `yaml:"no_color"`
+	OutputFormat    string     `yaml:"format"`
+	DirColor        string     `yaml:"dir_color"`
+	FileColor       string     `yaml:"file_color"`
+	ExecColor       string     `yaml:"exec_color"`
+	ExcludePatterns []string   `yaml:"exclude"`
+	SortBy          string     `yaml:"sort_by"` // comma-separated keys, see sortKeys
+	Order           string     `yaml:"order"`   // "asc", "desc"
+	IncludeHidden   bool       `yaml:"hidden"`
+	MaxDepth        int        `yaml:"max_depth"`     // -1 for no limit; 0, the zero value, reads only the root
+	IntoArchives    bool       `yaml:"into_archives"` // expand archives found during the walk as directories
+	Style           string     `yaml:"style"`         // tree drawing style, see treeStyles
+	CustomStyle     *TreeStyle `yaml:"custom_style"`  // used when Style is "custom"
+	Icons           bool       `yaml:"icons"`
+	IconSet         string     `yaml:"icon_set"`       // "nerd" or "emoji"
+	IconOverrides   *IconTable `yaml:"icon_overrides"` // entries added to or replacing the icon set
+	LSColors        bool       `yaml:"ls_colors"`      // color entries using the LS_COLORS environment variable
+	Theme           string     `yaml:"theme"`          // built-in theme name or path to a YAML theme file
+	Long            bool       `yaml:"long"`           // show long-listing columns left of the tree
+	Columns         []string   `yaml:"columns"`        // long-listing columns, see longColumns
+	TimeStyle       string     `yaml:"time_style"`     // "iso", "relative", "locale" or "ls"
+	HumanSizes      bool       `yaml:"human"`          // print sizes as 4.0K, 12M, ...
+	FullPath        bool       `yaml:"full_path"`      // print paths relative to the root instead of names
+	AbsolutePaths   bool       `yaml:"absolute"`       // print and record absolute paths
+	Fields          []string   `yaml:"fields"`         // columns of the csv and tsv formats, see listFields
+	NullTerminated  bool       `yaml:"null"`           // end list entries with NUL instead of newline
+	CollapseFiles   bool       `yaml:"collapse_files"` // replace the files of each directory with a count in diagrams
+	GraphDepth      int        `yaml:"graph_depth"`    // levels drawn in diagrams, 0 for all
+	ChartColor      string     `yaml:"chart_color"`    // "type" or "age", fill of treemap and sunburst files
+	LegacyOutput    bool       `yaml:"legacy_output"`  // print the bare Node tree instead of a Document in json, xml and yaml
+	Input           string     `yaml:"input"`          // layout file printed by the json, yaml or xml format, read instead of DirPath
+	Stats           bool       `yaml:"stats"`          // add a Summary to the text and structured formats
+	StatsTop        int        `yaml:"stats_top"`      // length of the ranked summary lists, 0 for defaultStatsTop
+	LOC             bool       `yaml:"loc"`            // count the lines of source files, summed per directory
+	MinSize         string     `yaml:"min_size"`       // smallest file shown, e.g. 10K
+	MaxSize         string     `yaml:"max_size"`       // largest file shown
+	NewerThan       string     `yaml:"newer_than"`     // age such as 2d, or a date; only entries modified after it are shown
+	OlderThan       string     `yaml:"older_than"`     // only entries modified before it are shown
+	Types           string     `yaml:"type"`           // comma-separated f, d, l and x (executable)
+	Empty           bool       `yaml:"empty"`          // only empty files and directories
+	Where           string     `yaml:"where"`          // filter expression, see Filter
+	Filter          *Filter    `yaml:"-"`              // compiled filter applied like Where, for library users
+	Find            string     `yaml:"find"`           // pattern of pr find; only matching entries and their ancestors are shown
+	FindMode        string     `yaml:"find_mode"`      // "glob", "regex" or "fuzzy"
+	Contains        string     `yaml:"contains"`       // regular expression; only files with a matching line are shown
+	ContainsLine    bool       `yaml:"contains_line"`  // show the first matching line of Contains
+	FileLimit       int        `yaml:"filelimit"`      // directories below the root with more entries are not read, 0 for no limit
+	MaxEntries      int        `yaml:"max_entries"`    // children shown per directory, 0 for all
+	DirsFirst       bool       `yaml:"dirs_first"`     // sort directories before files
+	FilesFirst      bool       `yaml:"files_first"`    // sort files before directories
+	IgnoreCase      bool       `yaml:"ignore_case"`    // compare names without case
+	Collate         string     `yaml:"collate"`        // language tag for name comparisons, or "locale" for the environment; byte order when empty
+
+	// Budgets of the walk; when one runs out, the tree read so far is printed
+	Timeout         time.Duration `yaml:"timeout"`           // 0 for no limit
+	MaxEntriesTotal int           `yaml:"max_entries_total"` // entries read, 0 for no limit
 }
 
-var colorMap = map[string]color.Attribute{
-	"black":   color.FgBlack,
-	"red":     color.FgRed,
-	"green":   color.FgGreen,
-	"yellow":  color.FgYellow,
-	"blue":    color.FgBlue,
-	"magenta": color.FgMagenta,
-	"cyan":    color.FgCyan,
-	"white":   color.FgWhite,
+// HandleFlags processes the configuration and prints the directory structure.
+func HandleFlags(config Config) {
+	if err := HandleFlagsContext(context.Background(), config); err != nil {
+		fmt.Println("Error:", err)
+	}
 }
 
-// getColorFunc returns a color function based on the color name
-func getColorFunc(colorName string) func(a ...interface{}) string {
-	if attr, ok := colorMap[colorName]; ok {
-		return color.New(attr).SprintFunc()
+// HandleFlagsContext is HandleFlags with a context. When ctx is done, the
+// tree read so far is printed, marked as partial. An invalid config is
+// returned as an error before anything is read; errors reading the tree are
+// printed as by HandleFlags.
+func HandleFlagsContext(ctx context.Context, config Config) error {
+	if err := ValidateConfig(config); err != nil {
+		return err
+	}
+
+	if config.Input != "" {
+		handleLayout(ctx, config)
+		return nil
+	}
+
+	// The root may be on a hung mount too
+	var absRoot string
+	fsys, err := interruptible(ctx, func() (fs.FS, error) {
+		fsys, root, err := openRoot(config.DirPath)
+		absRoot = root
+		return fsys, err
+	})
+	if err != nil {
+		fmt.Println("Error traversing directory:", err)
+		return nil
+	}
+
+	rootPath := "."
+	if config.AbsolutePaths {
+		rootPath = filepath.ToSlash(absRoot)
 	}
-	return fmt.Sprint // Default to no color if the color name is invalid
+	tree, err := buildTree(ctx, fsys, ".", filepath.Base(absRoot), rootPath, config)
+	if tree == nil {
+		fmt.Println("Error traversing directory:", err)
+		return nil
+	}
+
+	// Structured output records the absolute root
+	config.DirPath = absRoot
+	printTree(ctx, tree, config)
+	return nil
 }
 
-// HandleFlags processes the configuration and prints the directory structure.
-func HandleFlags(config Config) {
-	PrintProjectStructure(
-		config.DirPath,
-		config.OutputPath,
-		config.ExtFilter,
-		!config.NoColor,
-		config.OutputFormat,
-		config.DirColor,
-		config.FileColor,
-		config.ExecColor,
-		config.ExcludePatterns,
-		config.SortBy,
-		config.Order,
-		config.IncludeHidden,
-		config.MaxDepth)
+// handleLayout prints the tree read from the layout file config.Input,
+// applying the filters and sorting as if it were on disk.
+func handleLayout(ctx context.Context, config Config) {
+	layout, err := openLayout(config.Input)
+	if err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+
+	rootPath := "."
+	if config.AbsolutePaths && layout.root != "" {
+		rootPath = layout.root
+	}
+	tree, err := buildTree(ctx, layout, ".", layout.name, rootPath, config)
+	if tree == nil {
+		fmt.Println("Error traversing directory:", err)
+		return
+	}
+
+	config.DirPath = layout.root
+	if config.DirPath == "" {
+		config.DirPath = config.Input
+	}
+	printTree(ctx, tree, config)
+}
+
+// LoadConfig reads a YAML config file into config. Keys missing from the file
+// leave the corresponding fields untouched.
+func LoadConfig(path string, config *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// PrintFS prints the structure of fsys. config.DirPath is the slash-separated
+// fs.FS path to start from; an empty DirPath means the root of fsys. As with
+// BuildTree, config.MaxDepth must be -1 to print the whole tree.
+func PrintFS(fsys fs.FS, config Config) {
+	PrintFSContext(context.Background(), fsys, config)
+}
+
+// PrintFSContext is PrintFS with a context. When ctx is done before the walk
+// ends, the tree read so far is printed, marked as partial.
+func PrintFSContext(ctx context.Context, fsys fs.FS, config Config) {
+	if err := ValidateConfig(config); err != nil {
+		fmt.Println("Error:", err)
+		return
+	}
+
+	tree, err := BuildTreeContext(ctx, fsys, config)
+	if tree == nil {
+		fmt.Println("Error traversing directory:", err)
+		return
+	}
+
+	printTree(ctx, tree, config)
+}
+
+// printTree renders the tree in the configured format, prints it and
+// optionally writes it to the output file.
+func printTree(ctx context.Context, tree *Node, config Config) {
+	var output string
+	switch config.OutputFormat {
+	case "text":
+		opts, err := newTextOptions(tree, config)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		colors := opts.colors
+		opts.colors = plainPalette
+		output = getTreeOutput(tree, opts)
+		stats := ""
+		if config.Stats {
+			stats = getStatsOutput(newSummary(tree, config.StatsTop))
+			output += stats
+		}
+		if colorEnabled(config) {
+			opts.colors = colors
+			fmt.Print(getTreeOutput(tree, opts) + stats)
+		} else {
+			fmt.Print(output)
+		}
+	case "list", "csv", "tsv":
+		var err error
+		output, err = getListOutput(ctx, tree, config)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		fmt.Print(output)
+	case "markdown":
+		output = getMarkdownOutput(tree)
+		fmt.Print(output)
+	case "html":
+		output = getHTMLOutput(tree)
+		fmt.Print(output)
+	case "dot":
+		output = getDotOutput(tree, config)
+		fmt.Print(output)
+	case "mermaid":
+		output = getMermaidOutput(tree, config)
+		fmt.Print(output)
+	case "svg-treemap", "html-treemap", "svg-sunburst":
+		render := map[string]func(*Node, Config) (string, error){
+			"svg-treemap":  getTreemapSVG,
+			"html-treemap": getTreemapHTML,
+			"svg-sunburst": getSunburstSVG,
+		}[config.OutputFormat]
+		var err error
+		output, err = render(tree, config)
+		if err != nil {
+			fmt.Println("Error:", err)
+			return
+		}
+		fmt.Print(output)
+	case "json":
+		data, _ := json.MarshalIndent(structuredOutput(tree, config), "", "  ")
+		output = string(data)
+		fmt.Println(output)
+	case "xml":
+		data, _ := xml.MarshalIndent(structuredOutput(tree, config), "", "  ")
+		output = string(data)
+		if !config.LegacyOutput {
+			output = xml.Header + output
+		}
+		fmt.Println(output)
+	case "yaml":
+		data, _ := yaml.Marshal(structuredOutput(tree, config))
+		output = string(data)
+		fmt.Println(output)
+	default:
+		fmt.Println("Unsupported format:", config.OutputFormat)
+		return
+	}
+
+	if config.OutputPath != "" {
+		writeToFile(output, config.OutputPath)
+	}
+}
+
+// structuredOutput returns the value marshaled by the json, xml and yaml
+// formats: a Document, or the bare Node tree with LegacyOutput.
+func structuredOutput(tree *Node, config Config) any {
+	if config.LegacyOutput {
+		return tree
+	}
+	root := config.DirPath
+	if root == "" {
+		root = "."
+	}
+	return newDocument(tree, root, config)
 }
 
 // PrintProjectStructure prints the directory structure of the given root directory.
@@ -82,157 +303,157 @@ func PrintProjectStructure(
 	order string,
 	includeHidden bool,
 	maxDepth int) {
-	absRoot, err := filepath.Abs(root)
-	if err != nil {
-		fmt.Println("Error getting absolute path:", err)
-		return
-	}
+	HandleFlags(Config{
+		DirPath:         root,
+		OutputPath:      outputFile,
+		ExtFilter:       extFilter,
+		NoColor:         !useColor,
+		OutputFormat:    format,
+		DirColor:        dirColorName,
+		FileColor:       fileColorName,
+		ExecColor:       execColorName,
+		ExcludePatterns: excludePatterns,
+		SortBy:          sortBy,
+		Order:           order,
+		IncludeHidden:   includeHidden,
+		MaxDepth:        maxDepth,
+	})
+}
 
-	var output string
-	if format == "text" {
-		output = getTreeOutput(absRoot, extFilter, useColor, dirColorName, fileColorName, execColorName, excludePatterns, sortBy, order, includeHidden, maxDepth)
-	} else {
-		tree := buildTree(absRoot, extFilter, excludePatterns, sortBy, order, includeHidden, maxDepth, 0)
-		switch format {
-		case "json":
-			data, _ := json.MarshalIndent(tree, "", "  ")
-			output = string(data)
-		case "xml":
-			data, _ := xml.MarshalIndent(tree, "", "  ")
-			output = string(data)
-		case "yaml":
-			data, _ := yaml.Marshal(tree)
-			output = string(data)
-		default:
-			fmt.Println("Unsupported format:", format)
-			return
-		}
+// textOptions holds the resolved settings of the text renderer.
+type textOptions struct {
+	colors *palette
+	style  TreeStyle
+	icons  *IconTable     // nil unless --icons
+	long   *longFormatter // nil unless --long
 
-		fmt.Println(output)
-	}
+	fullPath bool // print node paths instead of names
+	absolute bool // the root path is absolute and printed too
+}
 
-	if outputFile != "" {
-		writeToFile(output, outputFile)
+// newTextOptions resolves the text settings of the config, reporting invalid
+// style, icon set, color or column names.
+func newTextOptions(tree *Node, config Config) (textOptions, error) {
+	opts := textOptions{
+		fullPath: config.FullPath || config.AbsolutePaths,
+		absolute: config.AbsolutePaths,
+	}
+	var err error
+	if opts.style, err = getTreeStyle(config); err != nil {
+		return opts, err
+	}
+	if opts.icons, err = getIconTable(config); err != nil {
+		return opts, err
+	}
+	if opts.colors, err = newPalette(config); err != nil {
+		return opts, err
 	}
+	if config.Long {
+		if opts.long, err = newLongFormatter(tree, config); err != nil {
+			return opts, err
+		}
+	}
+	return opts, nil
 }
 
-func getTreeOutput(root string, extFilter string, useColor bool, dirColorName string, fileColorName string, execColorName string, excludePatterns []string, sortBy string, order string, includeHidden bool, maxDepth int) string {
-	output := ""
+// getTreeOutput renders the tree in the classic text format.
+func getTreeOutput(tree *Node, opts textOptions) string {
+	var sb strings.Builder
 	dirCount := 0
 	fileCount := 0
-
-	dirColorFunc := getColorFunc(dirColorName)
-	fileColorFunc := getColorFunc(fileColorName)
-	execColorFunc := getColorFunc(execColorName)
-
-	var traverse func(string, string, int) error
-	traverse = func(currentDir string, prefix string, depth int) error {
-		if maxDepth != -1 && depth >= maxDepth {
-			return nil
+	colors, style, icons := opts.colors, opts.style, opts.icons
+
+	// label returns the name or path of a node, prefixed with its icon if
+	// icons are enabled
+	label := func(node *Node) string {
+		name := node.Name
+		if opts.fullPath {
+			name = node.Path
 		}
-		dir, err := os.Open(currentDir)
-		if err != nil {
-			return err
+		if icons == nil {
+			return name
 		}
-		defer dir.Close()
+		return icons.icon(node) + " " + name
+	}
 
-		entries, err := dir.Readdir(-1)
-		if err != nil {
-			return err
-		}
+	// paint colors the label of a node, highlighting the parts of its name
+	// matched by pr find
+	paint := func(node *Node) string {
+		return highlight(label(node), node.Name, node.matches, colors.colorFor(node), colors.match)
+	}
 
-		// Sort entries based on the specified criteria and order
-		sortEntries(entries, sortBy, order)
+	// columns returns the long-listing columns for a node, if enabled
+	columns := func(node *Node) string {
+		if opts.long == nil {
+			return ""
+		}
+		return opts.long.format(node, colors)
+	}
 
-		for i, entry := range entries {
-			if !includeHidden && strings.HasPrefix(entry.Name(), ".") {
-				continue
-			}
+	// blank pads lines without an entry to the long-listing columns
+	blank := ""
+	if opts.long != nil {
+		blank = opts.long.blank()
+	}
 
-			if isExcluded(entry.Name(), excludePatterns) {
-				if entry.IsDir() {
-					continue
-				}
-				continue
-			}
+	// lines returns the line counts of a node, if counted, and the content
+	// hits of a file, if searched
+	lines := func(node *Node) string {
+		s := ""
+		if node.loc != nil {
+			s = " [" + node.loc.String() + "]"
+		}
+		if node.hits != nil {
+			s += " " + node.hits.String()
+		}
+		return s
+	}
 
-			isLast := i == len(entries)-1
+	var render func(*Node, string)
+	render = func(node *Node, prefix string) {
+		for i, child := range node.Children {
+			isLast := i == len(node.Children)-1 && node.truncated == nil
 
-			if entry.IsDir() {
+			if child.IsDir {
 				dirCount++
-				if useColor {
-					fmt.Printf("%s%s/\n", prefix+getTreePrefix(isLast), dirColorFunc(entry.Name()))
-				} else {
-					fmt.Printf("%s%s/\n", prefix+getTreePrefix(isLast), entry.Name())
-				}
-				output += fmt.Sprintf("%s%s/\n", prefix+getTreePrefix(isLast), entry.Name())
-
-				err := traverse(filepath.Join(currentDir, entry.Name()), prefix+getIndent(isLast), depth+1)
-				if err != nil {
-					return err
+				sb.WriteString(fmt.Sprintf("%s%s%s/", columns(child), colors.branch(prefix+style.prefix(isLast)), paint(child)))
+				if child.err != nil {
+					sb.WriteString(" [error opening dir]")
 				}
-			} else {
-				if extFilter == "" || strings.HasSuffix(entry.Name(), extFilter) {
-					fileCount++
-					if useColor {
-						info, err := os.Stat(filepath.Join(currentDir, entry.Name()))
-						if err != nil {
-							fmt.Printf("%s%s\n", prefix+getTreePrefix(isLast), entry.Name())
-						} else if isExecutable(info) {
-							fmt.Printf("%s%s\n", prefix+getTreePrefix(isLast), execColorFunc(entry.Name()))
-						} else {
-							fmt.Printf("%s%s\n", prefix+getTreePrefix(isLast), fileColorFunc(entry.Name()))
-						}
-					} else {
-						fmt.Printf("%s%s\n", prefix+getTreePrefix(isLast), entry.Name())
-					}
-					output += fmt.Sprintf("%s%s/\n", prefix+getTreePrefix(isLast), entry.Name())
+				if child.truncated != nil && child.truncated.Reason == truncatedFileLimit {
+					sb.WriteString(" " + child.truncated.String())
 				}
+				sb.WriteString(lines(child) + "\n")
+				render(child, prefix+style.indent(isLast))
+				continue
 			}
+
+			fileCount++
+			name := paint(child)
+			if child.LinkTarget != "" {
+				name = fmt.Sprintf("%s -> %s", name, child.LinkTarget)
+			}
+			sb.WriteString(fmt.Sprintf("%s%s%s%s\n", columns(child), colors.branch(prefix+style.prefix(isLast)), name, lines(child)))
 		}
 
-		return nil
+		// Children left out by MaxEntries are summed up on a last line
+		if node.truncated != nil && node.truncated.Reason == truncatedMaxEntries {
+			sb.WriteString(fmt.Sprintf("%s%s%s\n", blank, colors.branch(prefix+style.prefix(true)), colors.summary(node.truncated.String())))
+		}
 	}
 
-	fmt.Printf("%s/\n", filepath.Base(root))
-	output += fmt.Sprintf("%s/\n", filepath.Base(root))
-
-	err := traverse(root, "", 0)
-	if err != nil {
-		fmt.Println("Error traversing directory:", err)
-		output += fmt.Sprintf("Error traversing directory: %v\n", err)
+	rootLabel := tree.Name
+	if opts.absolute {
+		rootLabel = tree.Path
 	}
-	fmt.Printf("\n%d directories, %d files\n", dirCount, fileCount)
-	output += fmt.Sprintf("\n%d directories, %d files\n", dirCount, fileCount)
-
-	return output
-}
-
-// sortEntries sorts the entries based on the specified criteria and order
-func sortEntries(entries []os.FileInfo, sortBy string, order string) {
-	switch sortBy {
-	case "name":
-		sort.Slice(entries, func(i, j int) bool {
-			if order == "asc" {
-				return entries[i].Name() < entries[j].Name()
-			}
-			return entries[i].Name() > entries[j].Name()
-		})
-	case "size":
-		sort.Slice(entries, func(i, j int) bool {
-			if order == "asc" {
-				return entries[i].Size() < entries[j].Size()
-			}
-			return entries[i].Size() > entries[j].Size()
-		})
-	case "time":
-		sort.Slice(entries, func(i, j int) bool {
-			if order == "asc" {
-				return entries[i].ModTime().Before(entries[j].ModTime())
-			}
-			return entries[i].ModTime().After(entries[j].ModTime())
-		})
+	sb.WriteString(fmt.Sprintf("%s%s/%s\n", columns(tree), rootLabel, lines(tree)))
+	render(tree, "")
+	sb.WriteString("\n" + colors.summary(fmt.Sprintf("%d directories, %d files", dirCount, fileCount)) + "\n")
+	if tree.partial != nil {
+		sb.WriteString(colors.summary("["+tree.partial.String()+"]") + "\n")
 	}
+
+	return sb.String()
 }
 
 // writeToFile writes the output to the specified file
@@ -251,92 +472,266 @@ func writeToFile(output, outputFile string) {
 // Node represents a directory or file in the tree structure
 type Node struct {
 	Name     string  `json:"name" xml:"name"`
+	Path     string  `json:"path" xml:"path"` // slash-separated, relative to the root unless absolute paths were requested
 	IsDir    bool    `json:"is_dir" xml:"is_dir"`
 	Children []*Node `json:"children,omitempty" xml:"children,omitempty"`
+
+	LinkTarget string `json:"link_target,omitempty" xml:"link_target,omitempty" yaml:"link_target,omitempty"`
+
+	info       fs.FileInfo  // metadata from the walk, nil when unknown
+	fsys       fs.FS        // file system the node was read from, nil when unknown
+	fsPath     string       // path of the node within fsys
+	err        error        // error reading this directory, if any
+	empty      bool         // directory that was read and has no entries
+	brokenLink bool         // symlink whose target does not exist
+	loc        *LineCounts  // with LOC, the lines of a source file or the sums of a directory
+	matches    [][2]int     // with Find, the byte ranges of Name matched by the pattern
+	hits       *ContentHits // with Contains, the matching lines of a file
+	truncated  *Truncation  // with FileLimit or MaxEntries, the entries left out of a directory
+	partial    *Partial     // on the root, why the walk stopped early
 }
 
-// buildTree constructs a tree of Nodes from the directory structure
-func buildTree(currentDir string, extFilter string, excludePatterns []string, sortBy string, order string, includeHidden bool, maxDepth int, depth int) *Node {
-	if maxDepth != -1 && depth >= maxDepth {
-		return nil
+// BuildTree walks fsys and constructs a tree of Nodes using the filters and
+// sorting in config. config.DirPath is the slash-separated fs.FS path to start
+// from; an empty DirPath means the root of fsys. With config.AbsolutePaths,
+// node paths are relative to the root of fsys rather than to DirPath.
+// config.MaxDepth must be -1 to walk the whole tree; its zero value builds
+// only the root.
+func BuildTree(fsys fs.FS, config Config) (*Node, error) {
+	return BuildTreeContext(context.Background(), fsys, config)
+}
+
+// BuildTreeContext is BuildTree with a context. When ctx is done, or the walk
+// runs into config.Timeout or config.MaxEntriesTotal, it returns the tree read
+// so far, marked as partial, with an error wrapping ctx.Err(),
+// context.DeadlineExceeded or ErrMaxEntriesTotal.
+func BuildTreeContext(ctx context.Context, fsys fs.FS, config Config) (*Node, error) {
+	root := config.DirPath
+	if root == "" {
+		root = "."
+	}
+	rootPath := "."
+	if config.AbsolutePaths {
+		rootPath = root
+	}
+	return buildTree(ctx, fsys, root, path.Base(root), rootPath, config)
+}
+
+// openRoot returns the file system for a root path on disk, which may be a
+// directory or an archive file, along with the absolute path of the root.
+func openRoot(root string) (fs.FS, string, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, "", err
+	}
+
+	info, err := os.Stat(absRoot)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if !info.IsDir() && isArchive(absRoot) {
+		archive, err := OpenArchive(DirFS(filepath.Dir(absRoot)), filepath.Base(absRoot))
+		return archive, absRoot, err
+	}
+	return DirFS(absRoot), absRoot, nil
+}
+
+// buildTree walks fsys from dir and returns the root node, named name.
+// rootPath is recorded as the path of the root; the paths of the other nodes
+// are joined onto it. A walk that stops early returns the partial tree along
+// with the reason.
+func buildTree(ctx context.Context, fsys fs.FS, dir string, name string, rootPath string, config Config) (*Node, error) {
+	if config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.Timeout)
+		defer cancel()
 	}
-	dir, err := os.Open(currentDir)
+
+	info, err := interruptible(ctx, func() (fs.FileInfo, error) { return fs.Stat(fsys, dir) })
 	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, &fs.PathError{Op: "walk", Path: dir, Err: errors.New("not a directory")}
+	}
+
+	root := &Node{
+		Name:   name,
+		Path:   rootPath,
+		IsDir:  true,
+		info:   info,
+		fsys:   fsys,
+		fsPath: dir,
+	}
+
+	w, err := newWalker(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.walk(fsys, dir, root, 0); err != nil {
+		return nil, err
+	}
+	// Reads interrupted after the last entry also stop the walk
+	if w.stopped() {
+		root.partial = newPartial(w.stop, w.visited)
+		return root, fmt.Errorf("walk stopped after %s: %w", plural(w.visited, "entry", "entries"), w.stop)
+	}
+	return root, nil
+}
+
+// walker holds the settings and progress of a walk.
+type walker struct {
+	ctx     context.Context
+	config  Config
+	filters *walkFilters
+	sorter  *entrySorter
+	visited int   // entries read so far
+	stop    error // why the walk stopped early, nil while it goes on
+}
+
+// newWalker parses the filters and sort order of config.
+func newWalker(ctx context.Context, config Config) (*walker, error) {
+	w := &walker{ctx: ctx, config: config}
+	var err error
+	if w.filters, err = newWalkFilters(config); err != nil {
+		return nil, err
+	}
+	if w.sorter, err = newEntrySorter(config); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// walk reads the entries of dir from fsys and appends the ones that pass the
+// filters to node. Errors reading subdirectories are recorded on the child
+// node so the rest of the tree can still be printed.
+func (w *walker) walk(fsys fs.FS, dir string, node *Node, depth int) error {
+	config, filters := w.config, w.filters
+	if config.MaxDepth != -1 && depth >= config.MaxDepth || w.stopped() {
 		return nil
 	}
-	defer dir.Close()
 
-	entries, err := dir.Readdir(-1)
+	entries, err := interruptible(w.ctx, func() ([]os.FileInfo, error) { return readDirInfo(fsys, dir) })
 	if err != nil {
+		if w.stopped() {
+			return nil
+		}
+		return err
+	}
+	node.empty = len(entries) == 0
+	if exceedsFileLimit(node, len(entries), config, depth) {
 		return nil
 	}
 
-	// Sort entries based on the specified criteria and order
-	sortEntries(entries, sortBy, order)
+	w.sorter.sort(entries)
 
-	node := &Node{
-		Name:  filepath.Base(currentDir),
-		IsDir: true,
-	}
+	// Files are searched in the background while the walk goes on
+	scans := map[*Node]<-chan *ContentHits{}
 
 	for _, entry := range entries {
-		if !includeHidden && strings.HasPrefix(entry.Name(), ".") {
+		childPath := path.Join(dir, entry.Name())
+		expand := config.IntoArchives && entry.Mode().IsRegular() && isArchive(entry.Name())
+		filterEntry := FilterEntry{
+			Path:  path.Join(node.Path, entry.Name()),
+			Depth: depth + 1,
+			Info:  entry,
+			IsDir: entry.IsDir() || expand,
+		}
+		if !filters.entries.Match(filterEntry) {
 			continue
 		}
+		if !w.visit() {
+			break
+		}
 
-		// Check if the entry matches any exclusion pattern
-		if isExcluded(entry.Name(), excludePatterns) {
-			continue
+		child := &Node{
+			Name:   entry.Name(),
+			Path:   path.Join(node.Path, entry.Name()),
+			IsDir:  entry.IsDir(),
+			info:   entry,
+			fsys:   fsys,
+			fsPath: childPath,
 		}
 
-		if entry.IsDir() {
-			child := buildTree(filepath.Join(currentDir, entry.Name()), extFilter, excludePatterns, sortBy, order, includeHidden, maxDepth, depth+1)
-			if child != nil {
-				node.Children = append(node.Children, child)
-			}
-		} else if extFilter == "" || strings.HasSuffix(entry.Name(), extFilter) {
-			node.Children = append(node.Children, &Node{
-				Name:  entry.Name(),
-				IsDir: false,
-			})
+		if entry.Mode()&fs.ModeSymlink != 0 {
+			child.LinkTarget, _ = interruptible(w.ctx, func() (string, error) { return readLink(fsys, childPath) })
+			_, err := interruptible(w.ctx, func() (fs.FileInfo, error) { return fs.Stat(fsys, childPath) })
+			child.brokenLink = err != nil && !w.stopped()
 		}
-	}
 
-	return node
-}
+		switch {
+		case entry.IsDir():
+			child.err = w.walk(fsys, childPath, child, depth+1)
+		case expand:
+			archive, err := interruptible(w.ctx, func() (fs.FS, error) { return OpenArchive(fsys, childPath) })
+			switch {
+			case w.stopped():
+			case err != nil:
+				child.err = err
+			default:
+				child.err = w.walk(archive, ".", child, depth+1)
+			}
+			child.IsDir = true
+		case !filters.matchFile(filterEntry):
+			continue
+		case filters.contents != nil && !entry.Mode().IsRegular():
+			continue
+		case filters.contents != nil:
+			scans[child] = filters.contents.scan(w.ctx, fsys, childPath)
+		}
+		if config.LOC && !child.IsDir && entry.Mode().IsRegular() {
+			child.loc, _ = interruptible(w.ctx, func() (*LineCounts, error) { return countFileLines(fsys, childPath), nil })
+		}
+		if child.IsDir && !filters.keepDir(w.ctx, child, filterEntry) {
+			continue
+		}
+		child.matches = filters.find.highlights(filterEntry)
 
-// isExecutable checks if a file is executable
-func isExecutable(entry os.FileInfo) bool {
-	return entry.Mode()&0111 != 0 // Check executable bits
-}
+		node.Children = append(node.Children, child)
+	}
+	if len(scans) > 0 {
+		resolveScans(w.ctx, node, scans)
+	}
 
-// getTreePrefix returns the tree prefix for the current entry.
-func getTreePrefix(isLast bool) string {
-	if isLast {
-		return "└── "
+	if config.LOC {
+		node.loc = &LineCounts{}
+		for _, child := range node.Children {
+			if child.loc != nil {
+				node.loc.add(child.loc)
+			}
+		}
 	}
-	return "├── "
+	limitEntries(node, config)
+	return nil
 }
 
-// getIndent returns the indentation for the current level.
-func getIndent(isLast bool) string {
-	if isLast {
-		return "    "
+// readDirInfo returns the file info of every entry in dir. Symlinks are not
+// followed when fsys implements FS.
+func readDirInfo(fsys fs.FS, dir string) ([]os.FileInfo, error) {
+	dirEntries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
 	}
-	return "│   "
-}
 
-// isExcluded checks if a file/directory matches any of the exclusion patterns
-func isExcluded(name string, excludePatterns []string) bool {
-	for _, pattern := range excludePatterns {
-		matched, err := filepath.Match(pattern, name)
-		if err != nil {
-			fmt.Printf("Invalid exclude pattern: %s\n", pattern)
-			continue
+	lfs, hasLstat := fsys.(FS)
+	entries := make([]os.FileInfo, 0, len(dirEntries))
+	for _, dirEntry := range dirEntries {
+		var info os.FileInfo
+		if hasLstat {
+			info, err = lfs.Lstat(path.Join(dir, dirEntry.Name()))
+		} else {
+			info, err = dirEntry.Info()
 		}
-		if matched {
-			return true
+		if err != nil {
+			continue // the entry vanished between listing and stat
 		}
+		entries = append(entries, info)
 	}
-	return false
+	return entries, nil
+}
+
+// isExecutable checks if a file is executable
+func isExecutable(entry os.FileInfo) bool {
+	return entry.Mode()&0111 != 0 // Check executable bits
 }
@@ -0,0 +1,183 @@
+package printer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"text/template"
+)
+
+// ScaffoldOptions controls how Scaffold creates a tree.
+type ScaffoldOptions struct {
+	DryRun      bool              // report what would be done without writing anything
+	NoOverwrite bool              // fail before writing anything if a file already exists
+	Force       bool              // replace existing files instead of keeping them
+	Templates   string            // directory holding <path>.tmpl contents for the files
+	Vars        map[string]string // values available to templates as {{.Vars.name}}
+}
+
+// templateData is passed to file templates.
+type templateData struct {
+	Project string            // base name of the target directory
+	Path    string            // slash-separated path of the file in the tree
+	Name    string            // base name of the file
+	Vars    map[string]string // ScaffoldOptions.Vars
+}
+
+// LoadTree reads the tree described by the file at name: a document printed
+// by the json, yaml or xml format, or a text tree.
+func LoadTree(name string) (*Node, error) {
+	layout, err := openLayout(name)
+	if err != nil {
+		return nil, err
+	}
+	return buildTree(context.Background(), layout, ".", layout.name, ".", Config{SortBy: "name", Order: "asc", IncludeHidden: true, MaxDepth: -1})
+}
+
+// Scaffold creates the directories, files and symlinks below the root of
+// tree inside the directory into, reporting every action to w. Files are
+// empty unless the templates directory has a <path>.tmpl for them, which is
+// executed with text/template. Existing directories are reused and existing
+// files are kept unless opts.Force is set.
+func Scaffold(tree *Node, into string, opts ScaffoldOptions, w io.Writer) error {
+	type action struct {
+		node    *Node
+		target  string
+		exists  bool
+		content []byte
+	}
+
+	project := filepath.Base(into)
+	if abs, err := filepath.Abs(into); err == nil {
+		project = filepath.Base(abs)
+	}
+
+	// Plan everything first so nothing is written when a check or a template
+	// fails
+	var actions []action
+	var plan func(*Node) error
+	plan = func(node *Node) error {
+		for _, child := range node.Children {
+			rel, err := scaffoldPath(child.Path)
+			if err != nil {
+				return err
+			}
+			target := filepath.Join(into, rel)
+			info, err := os.Lstat(target)
+			exists := err == nil
+			switch {
+			case exists && child.IsDir && !info.IsDir():
+				return fmt.Errorf("%s exists and is not a directory", target)
+			case exists && !child.IsDir && info.IsDir():
+				return fmt.Errorf("%s exists and is a directory", target)
+			case exists && !child.IsDir && opts.NoOverwrite:
+				return fmt.Errorf("%s already exists", target)
+			case err != nil && !errors.Is(err, fs.ErrNotExist):
+				return err
+			}
+			var content []byte
+			if !child.IsDir && child.LinkTarget == "" {
+				content, err = fileTemplate(opts, templateData{Project: project, Path: child.Path, Name: child.Name, Vars: opts.Vars})
+				if err != nil {
+					return err
+				}
+			}
+			actions = append(actions, action{child, target, exists, content})
+			if err := plan(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := plan(tree); err != nil {
+		return err
+	}
+
+	if !opts.DryRun {
+		if err := os.MkdirAll(into, 0755); err != nil {
+			return err
+		}
+	}
+	for _, a := range actions {
+		rel := a.node.Path
+		switch {
+		case a.node.IsDir:
+			if a.exists {
+				continue
+			}
+			fmt.Fprintf(w, "mkdir   %s/\n", rel)
+			if !opts.DryRun {
+				if err := os.Mkdir(a.target, 0755); err != nil {
+					return err
+				}
+			}
+		case a.exists && !opts.Force:
+			fmt.Fprintf(w, "keep    %s\n", rel)
+		case a.node.LinkTarget != "":
+			fmt.Fprintf(w, "symlink %s -> %s\n", rel, a.node.LinkTarget)
+			if !opts.DryRun {
+				if a.exists {
+					if err := os.Remove(a.target); err != nil {
+						return err
+					}
+				}
+				if err := os.Symlink(a.node.LinkTarget, a.target); err != nil {
+					return err
+				}
+			}
+		default:
+			verb := "create "
+			if a.exists {
+				verb = "replace"
+			}
+			fmt.Fprintf(w, "%s %s\n", verb, rel)
+			if !opts.DryRun {
+				if err := os.WriteFile(a.target, a.content, 0644); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// scaffoldPath checks that a node path stays inside the target directory
+// and converts it to a native relative path.
+func scaffoldPath(p string) (string, error) {
+	rel := filepath.FromSlash(p)
+	if !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("refusing to create %q outside the target directory", p)
+	}
+	return rel, nil
+}
+
+// fileTemplate returns the contents of a new file: its template executed
+// with data, or nothing when there is no template.
+func fileTemplate(opts ScaffoldOptions, data templateData) ([]byte, error) {
+	if opts.Templates == "" {
+		return nil, nil
+	}
+	name := filepath.Join(opts.Templates, filepath.FromSlash(data.Path)+".tmpl")
+	text, err := os.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := template.New(filepath.Base(name)).Option("missingkey=error").Parse(string(text))
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,139 @@
+package printer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestScaffold tests creating a tree from a text tree and from a layout
+// document.
+func TestScaffold(t *testing.T) {
+	dir := t.TempDir()
+	textTree := filepath.Join(dir, "tree.txt")
+	os.WriteFile(textTree, []byte("service/\n"+
+		"├── cmd/\n"+
+		"│   └── main.go\n"+
+		"├── docs/\n"+
+		"├── go.mod\n"+
+		"└── latest -> cmd\n"+
+		"\n2 directories, 3 files\n"), 0644)
+
+	t.Run("TextTree", func(t *testing.T) {
+		tree, err := LoadTree(textTree)
+		if err != nil {
+			t.Fatalf("LoadTree failed: %v", err)
+		}
+
+		into := filepath.Join(t.TempDir(), "svc")
+		var report strings.Builder
+		if err := Scaffold(tree, into, ScaffoldOptions{}, &report); err != nil {
+			t.Fatalf("Scaffold failed: %v", err)
+		}
+
+		expected := "mkdir   cmd/\n" +
+			"create  cmd/main.go\n" +
+			"mkdir   docs/\n" +
+			"create  go.mod\n" +
+			"symlink latest -> cmd\n"
+		if report.String() != expected {
+			t.Errorf("Unexpected report:\nGot:\n%s\nExpected:\n%s", report.String(), expected)
+		}
+		output := captureOutput(func() { HandleFlags(Config{DirPath: into, NoColor: true, OutputFormat: "text", MaxDepth: -1}) })
+		if want := "svc/\n├── cmd/\n│   └── main.go\n├── docs/\n├── go.mod\n└── latest -> cmd\n\n2 directories, 3 files\n"; output != want {
+			t.Errorf("Unexpected tree:\nGot:\n%s\nExpected:\n%s", output, want)
+		}
+	})
+
+	t.Run("Layout", func(t *testing.T) {
+		layout := filepath.Join(dir, "layout.json")
+		output := captureOutput(func() {
+			PrintFS(testProjectFS(), Config{OutputFormat: "json", SortBy: "name", Order: "asc", MaxDepth: -1, IncludeHidden: true})
+		})
+		os.WriteFile(layout, []byte(output), 0644)
+
+		tree, err := LoadTree(layout)
+		if err != nil {
+			t.Fatalf("LoadTree failed: %v", err)
+		}
+		into := t.TempDir()
+		if err := Scaffold(tree, into, ScaffoldOptions{}, &strings.Builder{}); err != nil {
+			t.Fatalf("Scaffold failed: %v", err)
+		}
+
+		config := Config{NoColor: true, OutputFormat: "text", SortBy: "name", Order: "asc", MaxDepth: -1, IncludeHidden: true}
+		got := captureOutput(func() { PrintFS(os.DirFS(into), config) })
+		want := captureOutput(func() { PrintFS(testProjectFS(), config) })
+		if got != want {
+			t.Errorf("Unexpected tree:\nGot:\n%s\nExpected:\n%s", got, want)
+		}
+	})
+
+	t.Run("ExistingFiles", func(t *testing.T) {
+		tree, _ := LoadTree(textTree)
+		into := t.TempDir()
+		os.WriteFile(filepath.Join(into, "go.mod"), []byte("module x\n"), 0644)
+
+		// Dry runs write nothing
+		var report strings.Builder
+		if err := Scaffold(tree, into, ScaffoldOptions{DryRun: true}, &report); err != nil {
+			t.Fatalf("Scaffold failed: %v", err)
+		}
+		if !strings.Contains(report.String(), "keep    go.mod\n") {
+			t.Errorf("Expected go.mod to be kept:\n%s", report.String())
+		}
+		if _, err := os.Stat(filepath.Join(into, "cmd")); err == nil {
+			t.Error("Dry run created cmd")
+		}
+
+		// Refusing to overwrite fails before anything is written
+		err := Scaffold(tree, into, ScaffoldOptions{NoOverwrite: true}, &strings.Builder{})
+		if err == nil || !strings.Contains(err.Error(), "go.mod already exists") {
+			t.Errorf("Expected an error for the existing go.mod, got %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(into, "cmd")); err == nil {
+			t.Error("Refused scaffold created cmd")
+		}
+
+		if err := Scaffold(tree, into, ScaffoldOptions{Force: true}, &strings.Builder{}); err != nil {
+			t.Fatalf("Scaffold failed: %v", err)
+		}
+		if data, _ := os.ReadFile(filepath.Join(into, "go.mod")); len(data) != 0 {
+			t.Errorf("Expected go.mod to be replaced, got %q", data)
+		}
+	})
+
+	t.Run("Templates", func(t *testing.T) {
+		tree, _ := LoadTree(textTree)
+		templates := t.TempDir()
+		os.WriteFile(filepath.Join(templates, "go.mod.tmpl"), []byte("module {{.Vars.module}}/{{.Project}}\n"), 0644)
+		into := filepath.Join(t.TempDir(), "billing")
+
+		opts := ScaffoldOptions{Templates: templates, Vars: map[string]string{"module": "example.com"}}
+		if err := Scaffold(tree, into, opts, &strings.Builder{}); err != nil {
+			t.Fatalf("Scaffold failed: %v", err)
+		}
+		if data, _ := os.ReadFile(filepath.Join(into, "go.mod")); string(data) != "module example.com/billing\n" {
+			t.Errorf("Unexpected go.mod: %q", data)
+		}
+
+		// Unknown variables are errors rather than "<no value>"
+		opts.Vars = map[string]string{}
+		into = t.TempDir()
+		if err := Scaffold(tree, into, opts, &strings.Builder{}); err == nil {
+			t.Error("Expected an error for a missing template variable")
+		}
+		if entries, _ := os.ReadDir(into); len(entries) != 0 {
+			t.Errorf("Expected nothing to be written, got %d entries", len(entries))
+		}
+	})
+
+	t.Run("OutsideTarget", func(t *testing.T) {
+		tree := &Node{Name: "x", Path: ".", IsDir: true, Children: []*Node{{Name: "..", Path: "../evil"}}}
+		err := Scaffold(tree, t.TempDir(), ScaffoldOptions{}, &strings.Builder{})
+		if err == nil || !strings.Contains(err.Error(), "outside the target directory") {
+			t.Errorf("Expected an error for a path outside the target, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,211 @@
+package printer
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/collate"
+	langtag "golang.org/x/text/language"
+)
+
+// sortKeys are the keys of Config.SortBy. Several can be combined with
+// commas, e.g. "ext,name"; later keys order the entries equal on earlier ones.
+var sortKeys = []string{"name", "natural", "version", "size", "time", "ext"}
+
+// entrySorter orders the entries of each directory. Entries equal on every
+// key are ordered by their names byte by byte, ascending also when the keys
+// are descending as ls does, so the order never depends on the file system.
+type entrySorter struct {
+	keys       []string
+	desc       bool
+	group      int // -1 to put directories first, 1 to put files first
+	ignoreCase bool
+	collator   *collate.Collator // nil to compare names byte by byte
+}
+
+// newEntrySorter parses the sort settings of config.
+func newEntrySorter(config Config) (*entrySorter, error) {
+	s := &entrySorter{ignoreCase: config.IgnoreCase}
+
+	for _, key := range strings.Split(config.SortBy, ",") {
+		key = strings.ToLower(strings.TrimSpace(key))
+		switch {
+		case key == "" && config.SortBy == "":
+			key = "name"
+		case !slices.Contains(sortKeys, key):
+			return nil, fmt.Errorf("--sort-by: unknown key %q (available: %s)", key, strings.Join(sortKeys, ", "))
+		}
+		s.keys = append(s.keys, key)
+	}
+
+	switch config.Order {
+	case "", "asc":
+	case "desc":
+		s.desc = true
+	default:
+		return nil, fmt.Errorf("--order: unknown order %q (available: asc, desc)", config.Order)
+	}
+
+	switch {
+	case config.DirsFirst && config.FilesFirst:
+		return nil, fmt.Errorf("--dirs-first and --files-first cannot be combined")
+	case config.DirsFirst:
+		s.group = -1
+	case config.FilesFirst:
+		s.group = 1
+	}
+
+	tag, err := collationTag(config.Collate)
+	if err != nil {
+		return nil, err
+	}
+	if tag != langtag.Und {
+		var options []collate.Option
+		if s.ignoreCase {
+			options = append(options, collate.IgnoreCase)
+		}
+		s.collator = collate.New(tag, options...)
+	}
+	return s, nil
+}
+
+// collationTag returns the language of name comparisons: a BCP 47 tag such
+// as "de" or "sv-SE", or "locale" for the language of LC_ALL, LC_COLLATE or
+// LANG. The C and POSIX locales and an empty value compare bytes, and are
+// returned as the undetermined language.
+func collationTag(value string) (langtag.Tag, error) {
+	if value == "locale" {
+		value = localeEnv("LC_COLLATE")
+	}
+	if value == "" || value == "C" || value == "POSIX" {
+		return langtag.Und, nil
+	}
+	tag, err := langtag.Parse(value)
+	if err != nil {
+		return langtag.Und, fmt.Errorf("--collate: invalid language %q", value)
+	}
+	return tag, nil
+}
+
+// localeEnv returns the locale of a category such as LC_COLLATE, taken from
+// LC_ALL, the category or LANG, as a BCP 47 tag: de_DE.UTF-8@euro is de-DE.
+// It is "" when none is set.
+func localeEnv(category string) string {
+	var value string
+	for _, name := range []string{"LC_ALL", category, "LANG"} {
+		if value = os.Getenv(name); value != "" {
+			break
+		}
+	}
+	value, _, _ = strings.Cut(value, ".")
+	value, _, _ = strings.Cut(value, "@")
+	return strings.ReplaceAll(value, "_", "-")
+}
+
+// sort orders entries in place. The grouping of directories and files is
+// not reversed by a descending order.
+func (s *entrySorter) sort(entries []os.FileInfo) {
+	slices.SortStableFunc(entries, s.compare)
+}
+
+// compare orders two entries.
+func (s *entrySorter) compare(a, b os.FileInfo) int {
+	if s.group != 0 && a.IsDir() != b.IsDir() {
+		if a.IsDir() {
+			return s.group
+		}
+		return -s.group
+	}
+
+	for _, key := range s.keys {
+		if c := s.compareKey(key, a, b); c != 0 {
+			if s.desc {
+				return -c
+			}
+			return c
+		}
+	}
+	return strings.Compare(a.Name(), b.Name())
+}
+
+// compareKey orders two entries by one sort key.
+func (s *entrySorter) compareKey(key string, a, b os.FileInfo) int {
+	switch key {
+	case "name":
+		return s.compareNames(a.Name(), b.Name())
+	case "natural", "version":
+		return naturalCompare(a.Name(), b.Name(), s.ignoreCase)
+	case "size":
+		return cmp.Compare(a.Size(), b.Size())
+	case "time":
+		return a.ModTime().Compare(b.ModTime())
+	case "ext":
+		return s.compareNames(sortExt(a), sortExt(b))
+	}
+	return 0
+}
+
+// compareNames orders two names by the collation, or without case.
+func (s *entrySorter) compareNames(a, b string) int {
+	switch {
+	case s.collator != nil:
+		return s.collator.CompareString(a, b)
+	case s.ignoreCase:
+		return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+	}
+	return strings.Compare(a, b)
+}
+
+// sortExt returns the lower-case extension of a file, or "" for directories,
+// which sort before any extension.
+func sortExt(info os.FileInfo) string {
+	if info.IsDir() {
+		return ""
+	}
+	return strings.ToLower(path.Ext(info.Name()))
+}
+
+// naturalCompare orders names with runs of digits compared by their value,
+// so file2 comes before file10 and v1.9 before v1.10.
+func naturalCompare(a, b string, ignoreCase bool) int {
+	for a != "" && b != "" {
+		da, db := digitPrefix(a), digitPrefix(b)
+		if da > 0 && db > 0 {
+			na, nb := strings.TrimLeft(a[:da], "0"), strings.TrimLeft(b[:db], "0")
+			if c := cmp.Compare(len(na), len(nb)); c != 0 {
+				return c
+			}
+			if c := strings.Compare(na, nb); c != 0 {
+				return c
+			}
+			a, b = a[da:], b[db:]
+			continue
+		}
+
+		ra, sizeA := utf8.DecodeRuneInString(a)
+		rb, sizeB := utf8.DecodeRuneInString(b)
+		if ignoreCase {
+			ra, rb = unicode.ToLower(ra), unicode.ToLower(rb)
+		}
+		if ra != rb {
+			return cmp.Compare(ra, rb)
+		}
+		a, b = a[sizeA:], b[sizeB:]
+	}
+	return cmp.Compare(len(a), len(b))
+}
+
+// digitPrefix returns the number of ASCII digits at the start of s.
+func digitPrefix(s string) int {
+	n := 0
+	for n < len(s) && s[n] >= '0' && s[n] <= '9' {
+		n++
+	}
+	return n
+}
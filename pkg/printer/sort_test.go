@@ -0,0 +1,102 @@
+package printer
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// TestSortEntries tests the sort keys, grouping and collation.
+func TestSortEntries(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, d) }
+	fsys := fstest.MapFS{
+		"file10.txt": {Data: make([]byte, 30), ModTime: day(1)},
+		"file2.txt":  {Data: make([]byte, 10), ModTime: day(2)},
+		"File3.md":   {Data: make([]byte, 10), ModTime: day(1)},
+		"äpfel.go":   {Data: make([]byte, 20), ModTime: day(3)},
+		"zebra.GO":   {Data: make([]byte, 20), ModTime: day(3)},
+		"v1.10":      {Mode: fs.ModeDir | 0755, ModTime: day(0)},
+		"v1.9":       {Mode: fs.ModeDir | 0755, ModTime: day(0)},
+	}
+
+	tests := []struct {
+		name     string
+		config   Config
+		expected string
+	}{
+		{"Name", Config{SortBy: "name"}, "File3.md file10.txt file2.txt v1.10/ v1.9/ zebra.GO äpfel.go"},
+		{"Default", Config{}, "File3.md file10.txt file2.txt v1.10/ v1.9/ zebra.GO äpfel.go"},
+		{"Natural", Config{SortBy: "natural"}, "File3.md file2.txt file10.txt v1.9/ v1.10/ zebra.GO äpfel.go"},
+		{"NaturalIgnoreCase", Config{SortBy: "version", IgnoreCase: true}, "file2.txt File3.md file10.txt v1.9/ v1.10/ zebra.GO äpfel.go"},
+		{"IgnoreCase", Config{SortBy: "name", IgnoreCase: true}, "file10.txt file2.txt File3.md v1.10/ v1.9/ zebra.GO äpfel.go"},
+		{"DirsFirst", Config{SortBy: "name", Order: "desc", DirsFirst: true}, "v1.9/ v1.10/ äpfel.go zebra.GO file2.txt file10.txt File3.md"},
+		{"FilesFirst", Config{SortBy: "natural", FilesFirst: true}, "File3.md file2.txt file10.txt zebra.GO äpfel.go v1.9/ v1.10/"},
+		{"SizeTies", Config{SortBy: "size", Order: "desc"}, "file10.txt zebra.GO äpfel.go File3.md file2.txt v1.10/ v1.9/"},
+		{"TimeThenSize", Config{SortBy: "time,size"}, "v1.10/ v1.9/ File3.md file10.txt file2.txt zebra.GO äpfel.go"},
+		{"ExtThenName", Config{SortBy: "ext, name", DirsFirst: true}, "v1.10/ v1.9/ zebra.GO äpfel.go File3.md file10.txt file2.txt"},
+		{"German", Config{SortBy: "name", Collate: "de"}, "äpfel.go file10.txt file2.txt File3.md v1.10/ v1.9/ zebra.GO"},
+		{"Swedish", Config{SortBy: "name", Collate: "sv"}, "file10.txt file2.txt File3.md v1.10/ v1.9/ zebra.GO äpfel.go"},
+	}
+	for _, test := range tests {
+		config := test.config
+		config.MaxDepth = -1
+		tree, err := BuildTree(fsys, config)
+		if err != nil {
+			t.Errorf("%s: BuildTree failed: %v", test.name, err)
+			continue
+		}
+		if got := listTree(tree); got != test.expected {
+			t.Errorf("%s:\ngot      %q\nexpected %q", test.name, got, test.expected)
+		}
+	}
+}
+
+// TestNaturalCompare tests numbers embedded in names.
+func TestNaturalCompare(t *testing.T) {
+	for _, test := range []struct {
+		a, b     string
+		expected int
+	}{
+		{"file2", "file10", -1},
+		{"v1.9.3", "v1.10.0", -1},
+		{"img007.png", "img7.png", 0},
+		{"a", "a1", -1},
+		{"build-12", "build-2", 1},
+		{"99999999999999999999x", "100000000000000000000", -1},
+		{"ärger2", "Ärger10", 1},
+	} {
+		if got := naturalCompare(test.a, test.b, false); got != test.expected {
+			t.Errorf("naturalCompare(%q, %q) = %d, expected %d", test.a, test.b, got, test.expected)
+		}
+	}
+	if naturalCompare("ärger2", "Ärger10", true) != -1 {
+		t.Error("Expected case to be ignored")
+	}
+}
+
+// TestSortErrors tests that invalid sort settings are reported.
+func TestSortErrors(t *testing.T) {
+	for _, test := range []struct {
+		config   Config
+		expected string
+	}{
+		{Config{SortBy: "name,colour"}, `--sort-by: unknown key "colour"`},
+		{Config{SortBy: "name,"}, `--sort-by: unknown key ""`},
+		{Config{Order: "up"}, `--order: unknown order "up"`},
+		{Config{DirsFirst: true, FilesFirst: true}, "cannot be combined"},
+		{Config{Collate: "not a language"}, `--collate: invalid language "not a language"`},
+	} {
+		_, err := BuildTree(fstest.MapFS{"a": {}}, test.config)
+		if err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("BuildTree(%+v) = %v, expected an error containing %q", test.config, err, test.expected)
+		}
+	}
+
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_COLLATE", "sv_SE.UTF-8")
+	if tag, err := collationTag("locale"); err != nil || tag.String() != "sv-SE" {
+		t.Errorf("collationTag(locale) = %v, %v, expected sv-SE", tag, err)
+	}
+}
@@ -0,0 +1,186 @@
+package printer
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// defaultStatsTop is the length of the largest, deepest, newest and oldest
+// lists when Config.StatsTop is not set.
+const defaultStatsTop = 10
+
+// Summary holds the statistics printed with --stats. Sizes count regular
+// files only; symlinks and directories have no size of their own.
+type Summary struct {
+	TotalBytes  int64            `json:"total_bytes" yaml:"total_bytes" xml:"total_bytes"`
+	Extensions  []ExtensionStats `json:"extensions" yaml:"extensions" xml:"extensions>extension"` // by bytes, largest first
+	Largest     []FileStats      `json:"largest" yaml:"largest" xml:"largest>file"`
+	Deepest     []FileStats      `json:"deepest" yaml:"deepest" xml:"deepest>entry"` // files and empty directories, deepest first
+	Newest      []FileStats      `json:"newest" yaml:"newest" xml:"newest>file"`
+	Oldest      []FileStats      `json:"oldest" yaml:"oldest" xml:"oldest>file"`
+	EmptyDirs   []string         `json:"empty_dirs" yaml:"empty_dirs" xml:"empty_dirs>path"`
+	Executables []string         `json:"executables" yaml:"executables" xml:"executables>path"`
+}
+
+// ExtensionStats counts the files with one extension; files without one are
+// counted under "(none)".
+type ExtensionStats struct {
+	Extension string `json:"extension" yaml:"extension" xml:"name,attr"`
+	Files     int    `json:"files" yaml:"files" xml:"files,attr"`
+	Bytes     int64  `json:"bytes" yaml:"bytes" xml:"bytes,attr"`
+}
+
+// FileStats is an entry of the summary lists. Only the fields relevant to
+// the list are set.
+type FileStats struct {
+	Path    string `json:"path" yaml:"path" xml:"path,attr"`
+	Size    int64  `json:"size,omitempty" yaml:"size,omitempty" xml:"size,attr,omitempty"`
+	Depth   int    `json:"depth,omitempty" yaml:"depth,omitempty" xml:"depth,attr,omitempty"`
+	ModTime string `json:"mod_time,omitempty" yaml:"mod_time,omitempty" xml:"mod_time,attr,omitempty"` // RFC 3339
+}
+
+// newSummary computes the statistics of the tree, keeping top entries in
+// each ranked list.
+func newSummary(tree *Node, top int) *Summary {
+	if top <= 0 {
+		top = defaultStatsTop
+	}
+	summary := &Summary{
+		Extensions:  []ExtensionStats{},
+		EmptyDirs:   []string{},
+		Executables: []string{},
+	}
+	extensions := map[string]*ExtensionStats{}
+	var files, leaves []*Node
+	depths := map[*Node]int{}
+
+	var visit func(node *Node, depth int)
+	visit = func(node *Node, depth int) {
+		for _, child := range node.Children {
+			depths[child] = depth + 1
+			// Directories left unread or without shown children are not
+			// leaves: their entries are unknown or filtered out
+			if !child.IsDir || child.empty {
+				leaves = append(leaves, child)
+			}
+			if child.IsDir {
+				if child.empty {
+					summary.EmptyDirs = append(summary.EmptyDirs, child.Path)
+				}
+				visit(child, depth+1)
+				continue
+			}
+			if nodeType(child) != "file" || child.info == nil {
+				continue
+			}
+
+			files = append(files, child)
+			size := child.info.Size()
+			summary.TotalBytes += size
+			ext := strings.ToLower(path.Ext(child.Name))
+			if ext == "" || ext == child.Name {
+				ext = "(none)"
+			}
+			if extensions[ext] == nil {
+				extensions[ext] = &ExtensionStats{Extension: ext}
+			}
+			extensions[ext].Files++
+			extensions[ext].Bytes += size
+			if isExecutable(child.info) {
+				summary.Executables = append(summary.Executables, child.Path)
+			}
+		}
+	}
+	visit(tree, 0)
+
+	for _, stats := range extensions {
+		summary.Extensions = append(summary.Extensions, *stats)
+	}
+	sort.Slice(summary.Extensions, func(i, j int) bool {
+		a, b := summary.Extensions[i], summary.Extensions[j]
+		if a.Bytes != b.Bytes {
+			return a.Bytes > b.Bytes
+		}
+		return a.Extension < b.Extension
+	})
+	sort.Strings(summary.EmptyDirs)
+	sort.Strings(summary.Executables)
+
+	// ranked sorts a copy of nodes, breaking ties by path, and keeps the
+	// first top entries
+	ranked := func(nodes []*Node, less func(a, b *Node) bool, stats func(*Node) FileStats) []FileStats {
+		nodes = append([]*Node{}, nodes...)
+		sort.SliceStable(nodes, func(i, j int) bool {
+			if less(nodes[i], nodes[j]) {
+				return true
+			}
+			if less(nodes[j], nodes[i]) {
+				return false
+			}
+			return nodes[i].Path < nodes[j].Path
+		})
+		list := []FileStats{}
+		for _, node := range nodes[:min(top, len(nodes))] {
+			list = append(list, stats(node))
+		}
+		return list
+	}
+	withSize := func(node *Node) FileStats { return FileStats{Path: node.Path, Size: node.info.Size()} }
+	withTime := func(node *Node) FileStats {
+		return FileStats{Path: node.Path, ModTime: node.info.ModTime().UTC().Format(time.RFC3339)}
+	}
+
+	summary.Largest = ranked(files, func(a, b *Node) bool { return a.info.Size() > b.info.Size() }, withSize)
+	summary.Deepest = ranked(leaves, func(a, b *Node) bool { return depths[a] > depths[b] },
+		func(node *Node) FileStats { return FileStats{Path: node.Path, Depth: depths[node]} })
+	summary.Newest = ranked(files, func(a, b *Node) bool { return a.info.ModTime().After(b.info.ModTime()) }, withTime)
+	summary.Oldest = ranked(files, func(a, b *Node) bool { return a.info.ModTime().Before(b.info.ModTime()) }, withTime)
+	return summary
+}
+
+// getStatsOutput renders the summary below the text tree. Empty sections
+// are left out.
+func getStatsOutput(summary *Summary) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "\nTotal size: %s (%d bytes)\n", humanSize(summary.TotalBytes), summary.TotalBytes)
+
+	section := func(title string, rows []string) {
+		if len(rows) == 0 {
+			return
+		}
+		fmt.Fprintf(&sb, "\n%s:\n", title)
+		tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+		for _, row := range rows {
+			fmt.Fprintf(tw, "  %s\n", row)
+		}
+		tw.Flush()
+	}
+	rows := func(list []FileStats, format func(FileStats) string) []string {
+		var out []string
+		for _, stats := range list {
+			out = append(out, format(stats)+"\t"+stats.Path)
+		}
+		return out
+	}
+	modTime := func(stats FileStats) string {
+		t, _ := time.Parse(time.RFC3339, stats.ModTime)
+		return t.Format("2006-01-02 15:04")
+	}
+
+	var extensions []string
+	for _, ext := range summary.Extensions {
+		extensions = append(extensions, fmt.Sprintf("%s\t%s\t%s", ext.Extension, plural(ext.Files, "file", "files"), humanSize(ext.Bytes)))
+	}
+	section("By extension", extensions)
+	section("Largest files", rows(summary.Largest, func(s FileStats) string { return humanSize(s.Size) }))
+	section("Deepest paths", rows(summary.Deepest, func(s FileStats) string { return fmt.Sprint(s.Depth) }))
+	section("Newest files", rows(summary.Newest, modTime))
+	section("Oldest files", rows(summary.Oldest, modTime))
+	section("Empty directories", summary.EmptyDirs)
+	section("Executables", summary.Executables)
+	return sb.String()
+}
@@ -0,0 +1,143 @@
+package printer
+
+import (
+	"encoding/json"
+	"io/fs"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// statsFS returns a tree with known sizes, times and modes.
+func statsFS() fstest.MapFS {
+	day := func(d int) time.Time { return time.Date(2024, 5, d, 12, 0, 0, 0, time.UTC) }
+	return fstest.MapFS{
+		"README.md":         {Data: make([]byte, 300), ModTime: day(1)},
+		"cmd/main.go":       {Data: make([]byte, 2000), ModTime: day(3)},
+		"pkg/a/b/deep.go":   {Data: make([]byte, 100), ModTime: day(2)},
+		"pkg/a/b/Notes.TXT": {Data: make([]byte, 50), ModTime: day(4)},
+		"scripts/build":     {Data: make([]byte, 10), Mode: 0755, ModTime: day(5)},
+		"scripts/latest":    {Data: []byte("build"), Mode: fs.ModeSymlink | 0777, ModTime: day(6)},
+		"empty":             {Mode: fs.ModeDir | 0755},
+		"pkg/a/also-empty":  {Mode: fs.ModeDir | 0755},
+	}
+}
+
+// TestSummary tests every list of the summary.
+func TestSummary(t *testing.T) {
+	tree, err := BuildTree(statsFS(), Config{SortBy: "name", Order: "asc", MaxDepth: -1})
+	if err != nil {
+		t.Fatalf("BuildTree failed: %v", err)
+	}
+	summary := newSummary(tree, 2)
+
+	expected := &Summary{
+		TotalBytes: 2460,
+		Extensions: []ExtensionStats{
+			{".go", 2, 2100},
+			{".md", 1, 300},
+			{".txt", 1, 50},
+			{"(none)", 1, 10},
+		},
+		Largest: []FileStats{{Path: "cmd/main.go", Size: 2000}, {Path: "README.md", Size: 300}},
+		Deepest: []FileStats{{Path: "pkg/a/b/Notes.TXT", Depth: 4}, {Path: "pkg/a/b/deep.go", Depth: 4}},
+		Newest: []FileStats{
+			{Path: "scripts/build", ModTime: "2024-05-05T12:00:00Z"},
+			{Path: "pkg/a/b/Notes.TXT", ModTime: "2024-05-04T12:00:00Z"},
+		},
+		Oldest: []FileStats{
+			{Path: "README.md", ModTime: "2024-05-01T12:00:00Z"},
+			{Path: "pkg/a/b/deep.go", ModTime: "2024-05-02T12:00:00Z"},
+		},
+		EmptyDirs:   []string{"empty", "pkg/a/also-empty"},
+		Executables: []string{"scripts/build"},
+	}
+	if !reflect.DeepEqual(summary, expected) {
+		t.Errorf("Unexpected summary:\nGot:      %+v\nExpected: %+v", summary, expected)
+	}
+}
+
+// TestSummaryUnreadDirs tests that directories left unread by the depth and
+// entry limits or emptied by filters are not counted as empty.
+func TestSummaryUnreadDirs(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		config   Config
+		expected []string
+	}{
+		{"MaxDepth", Config{MaxDepth: 1}, []string{}},
+		{"FileLimit", Config{MaxDepth: -1, FileLimit: 1}, []string{"empty"}},
+		{"Filtered", Config{MaxDepth: -1, ExtFilter: ".none"}, []string{"empty", "pkg/a/also-empty"}},
+	} {
+		config := test.config
+		config.SortBy, config.Order = "name", "asc"
+		tree, err := BuildTree(statsFS(), config)
+		if err != nil {
+			t.Fatalf("%s: BuildTree failed: %v", test.name, err)
+		}
+		summary := newSummary(tree, 10)
+		if !reflect.DeepEqual(summary.EmptyDirs, test.expected) {
+			t.Errorf("%s: got empty directories %v, expected %v", test.name, summary.EmptyDirs, test.expected)
+		}
+		for _, entry := range summary.Deepest {
+			if entry.Path == "cmd" || entry.Path == "scripts" || entry.Path == "pkg" || entry.Path == "pkg/a/b" {
+				t.Errorf("%s: unexpected deepest entry %s", test.name, entry.Path)
+			}
+		}
+	}
+}
+
+// TestStatsOutput tests the summary printed below the text tree and in the
+// structured formats.
+func TestStatsOutput(t *testing.T) {
+	config := Config{NoColor: true, OutputFormat: "text", SortBy: "name", Order: "asc", MaxDepth: -1, Stats: true, StatsTop: 1}
+	output := captureOutput(func() { PrintFS(statsFS(), config) })
+
+	expected := "7 directories, 6 files\n" +
+		"\n" +
+		"Total size: 2.4K (2460 bytes)\n" +
+		"\n" +
+		"By extension:\n" +
+		"  .go     2 files  2.1K\n" +
+		"  .md     1 file   300\n" +
+		"  .txt    1 file   50\n" +
+		"  (none)  1 file   10\n" +
+		"\n" +
+		"Largest files:\n" +
+		"  2.0K  cmd/main.go\n" +
+		"\n" +
+		"Deepest paths:\n" +
+		"  4  pkg/a/b/Notes.TXT\n" +
+		"\n" +
+		"Newest files:\n" +
+		"  2024-05-05 12:00  scripts/build\n" +
+		"\n" +
+		"Oldest files:\n" +
+		"  2024-05-01 12:00  README.md\n" +
+		"\n" +
+		"Empty directories:\n" +
+		"  empty\n" +
+		"  pkg/a/also-empty\n" +
+		"\n" +
+		"Executables:\n" +
+		"  scripts/build\n"
+	if !strings.HasSuffix(output, expected) {
+		t.Errorf("Unexpected output:\n%s\nExpected it to end with:\n%s", output, expected)
+	}
+
+	config.OutputFormat = "json"
+	var doc Document
+	if err := json.Unmarshal([]byte(captureOutput(func() { PrintFS(statsFS(), config) })), &doc); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	if doc.Summary == nil || doc.Summary.TotalBytes != 2460 || len(doc.Summary.Largest) != 1 {
+		t.Errorf("Unexpected summary: %+v", doc.Summary)
+	}
+
+	config.Stats = false
+	if output := captureOutput(func() { PrintFS(statsFS(), config) }); strings.Contains(output, "summary") {
+		t.Errorf("Expected no summary without Stats:\n%s", output)
+	}
+}
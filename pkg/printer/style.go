@@ -0,0 +1,89 @@
+package printer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// TreeStyle holds the strings used to draw the branches of the text tree.
+type TreeStyle struct {
+	Branch   string `yaml:"branch"`   // prefix for an entry with siblings below it
+	Last     string `yaml:"last"`     // prefix for the last entry of a directory
+	Vertical string `yaml:"vertical"` // indent under an entry with siblings below it
+	Space    string `yaml:"space"`    // indent under the last entry of a directory
+}
+
+// treeStyles are the built-in styles selectable with --style.
+var treeStyles = map[string]TreeStyle{
+	"unicode": {Branch: "├── ", Last: "└── ", Vertical: "│   ", Space: "    "},
+	"ascii":   {Branch: "|-- ", Last: "`-- ", Vertical: "|   ", Space: "    "},
+	"rounded": {Branch: "├── ", Last: "╰── ", Vertical: "│   ", Space: "    "},
+	"heavy":   {Branch: "┣━━ ", Last: "┗━━ ", Vertical: "┃   ", Space: "    "},
+	"double":  {Branch: "╠══ ", Last: "╚══ ", Vertical: "║   ", Space: "    "},
+	"indent":  {Branch: "  ", Last: "  ", Vertical: "  ", Space: "  "},
+}
+
+// getTreeStyle returns the style selected in the config. The "custom" style
+// comes from config.CustomStyle.
+func getTreeStyle(config Config) (TreeStyle, error) {
+	name := config.Style
+	if name == "" {
+		name = "unicode"
+	}
+
+	if name == "custom" {
+		if config.CustomStyle == nil {
+			return TreeStyle{}, fmt.Errorf("style \"custom\" requires custom_style in the config file")
+		}
+		return config.CustomStyle.aligned(), nil
+	}
+
+	style, ok := treeStyles[name]
+	if !ok {
+		return TreeStyle{}, fmt.Errorf("unknown style %q (available: %s, custom)", name, strings.Join(styleNames(), ", "))
+	}
+	return style, nil
+}
+
+// styleNames returns the sorted names of the built-in styles.
+func styleNames() []string {
+	names := make([]string, 0, len(treeStyles))
+	for name := range treeStyles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// aligned pads the parts of the style with spaces to a common display
+// width, so children line up under their parent whatever characters a
+// custom style uses. Wide characters count as two columns.
+func (s TreeStyle) aligned() TreeStyle {
+	width := 0
+	for _, part := range []string{s.Branch, s.Last, s.Vertical, s.Space} {
+		width = max(width, runewidth.StringWidth(part))
+	}
+	pad := func(part string) string {
+		return part + strings.Repeat(" ", width-runewidth.StringWidth(part))
+	}
+	return TreeStyle{Branch: pad(s.Branch), Last: pad(s.Last), Vertical: pad(s.Vertical), Space: pad(s.Space)}
+}
+
+// prefix returns the tree prefix for the current entry.
+func (s TreeStyle) prefix(isLast bool) string {
+	if isLast {
+		return s.Last
+	}
+	return s.Branch
+}
+
+// indent returns the indentation for the current level.
+func (s TreeStyle) indent(isLast bool) string {
+	if isLast {
+		return s.Space
+	}
+	return s.Vertical
+}
@@ -0,0 +1,111 @@
+package printer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// TestTreeStyles tests rendering with the built-in and custom styles.
+func TestTreeStyles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"src/main.go": {},
+		"README.md":   {},
+	}
+	config := Config{NoColor: true, OutputFormat: "text", SortBy: "name", Order: "asc", MaxDepth: -1}
+
+	tests := []struct {
+		name     string
+		style    string
+		custom   *TreeStyle
+		expected string
+	}{
+		{
+			name:  "ASCII",
+			style: "ascii",
+			expected: "./\n" +
+				"|-- README.md\n" +
+				"`-- src/\n" +
+				"    `-- main.go\n",
+		},
+		{
+			name:  "Indent",
+			style: "indent",
+			expected: "./\n" +
+				"  README.md\n" +
+				"  src/\n" +
+				"    main.go\n",
+		},
+		{
+			name:   "CustomWide",
+			style:  "custom",
+			custom: &TreeStyle{Branch: "＋ ", Last: "＋ ", Vertical: "｜", Space: ""},
+			expected: "./\n" +
+				"＋ README.md\n" +
+				"＋ src/\n" +
+				"   ＋ main.go\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := config
+			config.Style = tt.style
+			config.CustomStyle = tt.custom
+			output := captureOutput(func() { PrintFS(fsys, config) })
+
+			expected := tt.expected + "\n1 directories, 2 files\n"
+			if output != expected {
+				t.Errorf("Unexpected output:\nGot:\n%s\nExpected:\n%s", output, expected)
+			}
+		})
+	}
+
+	t.Run("Unknown", func(t *testing.T) {
+		config := config
+		config.Style = "fancy"
+		output := captureOutput(func() { PrintFS(fsys, config) })
+
+		if !strings.HasPrefix(output, "Error: unknown style \"fancy\"") {
+			t.Errorf("Expected an unknown style error, got:\n%s", output)
+		}
+	})
+}
+
+// TestLoadConfig tests reading a custom style from a YAML config file.
+func TestLoadConfig(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "pr.yaml")
+	data := "style: custom\n" +
+		"custom_style:\n" +
+		"  branch: \"+-> \"\n" +
+		"  last: \"\\\\-> \"\n" +
+		"  vertical: \"|\"\n" +
+		"exclude:\n" +
+		"  - \"*.log\"\n"
+	if err := os.WriteFile(configPath, []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	config := Config{Style: "unicode", MaxDepth: -1}
+	if err := LoadConfig(configPath, &config); err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	if config.MaxDepth != -1 {
+		t.Errorf("Expected MaxDepth to be left untouched, got %d", config.MaxDepth)
+	}
+	if len(config.ExcludePatterns) != 1 || config.ExcludePatterns[0] != "*.log" {
+		t.Errorf("Unexpected exclude patterns: %v", config.ExcludePatterns)
+	}
+
+	style, err := getTreeStyle(config)
+	if err != nil {
+		t.Fatalf("getTreeStyle failed: %v", err)
+	}
+	expected := TreeStyle{Branch: "+-> ", Last: "\\-> ", Vertical: "|   ", Space: "    "}
+	if style != expected {
+		t.Errorf("Unexpected style: %#v", style)
+	}
+}
@@ -0,0 +1,170 @@
+package printer
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// summaryLine matches the "N directories, M files" line ending a text tree.
+var summaryLine = regexp.MustCompile(`^\d+ director(y|ies), \d+ files?$`)
+
+// partialLine matches the marker printed after the summary of a walk that
+// stopped early.
+var partialLine = regexp.MustCompile(`^\[partial tree: .*\]$`)
+
+// moreLine matches the entry counting the children left out by --max-entries.
+var moreLine = regexp.MustCompile(`^… and \d+ more \(.*\)$`)
+
+// fileLimitSuffix matches the marker after a directory left unread by
+// --filelimit.
+var fileLimitSuffix = regexp.MustCompile(` \[\d+ entr(y|ies) exceeds filelimit, not opening dir\]$`)
+
+// ansiEscape matches the color codes of pasted colorized output.
+var ansiEscape = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// ParseTextTree reads a tree diagram as printed by the text format in any of
+// the built-in styles except indent, or by GNU tree with the unicode or ascii
+// charset. Colors, a surrounding Markdown code fence, a common indentation,
+// the summary line and the markers of entries left out are ignored.
+// Directories are entries with a trailing slash or with children;
+// "name -> target" entries are symlinks.
+func ParseTextTree(r io.Reader) (*Node, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := ansiEscape.ReplaceAllString(scanner.Text(), "")
+		// GNU tree pads its vertical bars with non-breaking spaces
+		line = strings.ReplaceAll(line, "\u00a0", " ")
+		lines = append(lines, strings.TrimRight(line, " \t\r"))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// Drop the summary, the partial tree marker, code fences and the blank
+	// lines around the tree
+	trim := func(line string) bool {
+		line = strings.TrimSpace(line)
+		return line == "" || strings.HasPrefix(line, "```") || summaryLine.MatchString(line) || partialLine.MatchString(line)
+	}
+	for len(lines) > 0 && trim(lines[len(lines)-1]) {
+		lines = lines[:len(lines)-1]
+	}
+	for len(lines) > 0 && trim(lines[0]) {
+		lines = lines[1:]
+	}
+	if len(lines) == 0 {
+		return nil, errors.New("the tree is empty")
+	}
+
+	// Remove the indentation of the whole diagram, e.g. in a Markdown list
+	margin := lines[0][:len(lines[0])-len(strings.TrimLeft(lines[0], " \t"))]
+	for i, line := range lines {
+		lines[i] = strings.TrimPrefix(line, margin)
+	}
+
+	root := &Node{Name: strings.TrimSuffix(lines[0], "/"), Path: ".", IsDir: true}
+	if root.Name == "" {
+		root.Name = "/"
+	}
+	stack := []*Node{root}
+	for i, line := range lines[1:] {
+		depth, label, ok := splitTreeLine(line)
+		if !ok {
+			return nil, fmt.Errorf("line %d: no tree branch in %q", i+2, line)
+		}
+		if depth > len(stack) {
+			return nil, fmt.Errorf("line %d: %q is indented deeper than its parent", i+2, line)
+		}
+
+		parent := stack[depth-1]
+		parent.IsDir = true
+		if moreLine.MatchString(label) {
+			continue // the names of the entries left out are unknown
+		}
+		child := parseTreeLabel(label)
+		child.Path = path.Join(parent.Path, child.Name)
+		parent.Children = append(parent.Children, child)
+		stack = append(stack[:depth], child)
+	}
+	return root, nil
+}
+
+// looksLikeTextTree reports whether data holds a drawn text tree rather than
+// a structured document: some line starts with a branch.
+func looksLikeTextTree(data []byte) bool {
+	trimmed := strings.TrimSpace(string(data))
+	if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "<") {
+		return false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.ReplaceAll(strings.TrimSpace(ansiEscape.ReplaceAllString(line, "")), "\u00a0", " ")
+		if _, _, ok := splitTreeLine(line); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// splitTreeLine removes the drawing characters from a line, returning the
+// depth of the entry (1 for children of the root) and its label.
+func splitTreeLine(line string) (int, string, bool) {
+	depth := 1
+	for {
+		for _, style := range treeStyles {
+			if style.Branch == style.Space {
+				continue // the indent style cannot be told apart from names
+			}
+			for _, branch := range []string{style.Branch, style.Last} {
+				if label, ok := strings.CutPrefix(line, branch); ok {
+					return depth, label, true
+				}
+			}
+		}
+
+		indented := false
+		for _, style := range treeStyles {
+			if style.Branch == style.Space {
+				continue
+			}
+			for _, indent := range []string{style.Vertical, style.Space} {
+				if rest, ok := strings.CutPrefix(line, indent); ok {
+					line, indented = rest, true
+					break
+				}
+			}
+			if indented {
+				break
+			}
+		}
+		if !indented {
+			return 0, "", false
+		}
+		depth++
+	}
+}
+
+// parseTreeLabel builds a node from the label of an entry as printed by the
+// text format.
+func parseTreeLabel(label string) *Node {
+	node := &Node{}
+	if name, ok := strings.CutSuffix(label, " [error opening dir]"); ok {
+		label, node.IsDir, node.err = name, true, errors.New("error opening dir")
+	}
+	if loc := fileLimitSuffix.FindStringIndex(label); loc != nil {
+		label, node.IsDir = label[:loc[0]], true
+	}
+	if name, target, ok := strings.Cut(label, " -> "); ok {
+		label, node.LinkTarget = name, target
+	}
+	if name, ok := strings.CutSuffix(label, "/"); ok {
+		label, node.IsDir = name, true
+	}
+	node.Name = label
+	return node
+}
@@ -0,0 +1,165 @@
+package printer
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// flatten lists the path, type and link target of every node for
+// comparisons.
+func flatten(node *Node) []string {
+	entry := fmt.Sprintf("%s dir=%v", node.Path, node.IsDir)
+	if node.LinkTarget != "" {
+		entry += " -> " + node.LinkTarget
+	}
+	entries := []string{entry}
+	for _, child := range node.Children {
+		entries = append(entries, flatten(child)...)
+	}
+	return entries
+}
+
+// TestParseTextTreeRoundTrip tests that the text output of every style
+// parses back into the tree it was printed from.
+func TestParseTextTreeRoundTrip(t *testing.T) {
+	fsys := testProjectFS()
+	fsys["bin/latest"] = &fstest.MapFile{Mode: fs.ModeSymlink | 0777, Data: []byte("pr")}
+	fsys["empty"] = &fstest.MapFile{Mode: fs.ModeDir | 0755}
+	config := Config{NoColor: true, OutputFormat: "text", SortBy: "name", Order: "asc", MaxDepth: -1, IncludeHidden: true}
+
+	tree, err := BuildTree(fsys, config)
+	if err != nil {
+		t.Fatalf("BuildTree failed: %v", err)
+	}
+	expected := strings.Join(flatten(tree), "\n")
+
+	for _, style := range styleNames() {
+		if style == "indent" {
+			continue
+		}
+		config := config
+		config.Style = style
+		output := captureOutput(func() { PrintFS(fsys, config) })
+
+		parsed, err := ParseTextTree(strings.NewReader(output))
+		if err != nil {
+			t.Errorf("%s: ParseTextTree failed: %v", style, err)
+			continue
+		}
+		if got := strings.Join(flatten(parsed), "\n"); got != expected {
+			t.Errorf("%s: unexpected tree:\nGot:\n%s\nExpected:\n%s", style, got, expected)
+		}
+	}
+}
+
+// TestParseTextTreeMarkers tests that the markers of entries left out by
+// the entry limits and of a partial walk are not read as entries.
+func TestParseTextTreeMarkers(t *testing.T) {
+	budget := budgetFS()
+	close(budget.release)
+	tests := []struct {
+		name     string
+		fsys     fs.FS
+		config   Config
+		marker   string
+		rendered string
+	}{
+		{"MaxEntries", limitsFS(), Config{MaxEntries: 4, FileLimit: 100}, "… and 9 more (72 KB)", "2 directories, 7 files"},
+		{"FileLimit", limitsFS(), Config{FileLimit: 13}, "node_modules/ [30 entries exceeds filelimit, not opening dir]", "3 directories, 16 files"},
+		{"Partial", budget, Config{MaxEntriesTotal: 4}, "[partial tree: stopped at the limit of 4 entries]", "1 directories, 3 files"},
+	}
+	for _, test := range tests {
+		config := test.config
+		config.NoColor, config.OutputFormat, config.SortBy, config.Order, config.MaxDepth = true, "text", "name", "asc", -1
+		tree, _ := BuildTree(test.fsys, config)
+		output := captureOutput(func() { PrintFS(test.fsys, config) })
+		if !strings.Contains(output, test.marker) {
+			t.Errorf("%s: expected %q in the output:\n%s", test.name, test.marker, output)
+			continue
+		}
+
+		parsed, err := ParseTextTree(strings.NewReader(output))
+		if err != nil {
+			t.Errorf("%s: ParseTextTree failed: %v", test.name, err)
+			continue
+		}
+		if got, expected := strings.Join(flatten(parsed), "\n"), strings.Join(flatten(tree), "\n"); got != expected {
+			t.Errorf("%s: unexpected tree:\nGot:\n%s\nExpected:\n%s", test.name, got, expected)
+		}
+
+		// The entries shown are rendered again
+		input := filepath.Join(t.TempDir(), "tree.txt")
+		os.WriteFile(input, []byte(output), 0644)
+		rendered := captureOutput(func() {
+			HandleFlags(Config{Input: input, NoColor: true, OutputFormat: "text", SortBy: "name", Order: "asc", MaxDepth: -1})
+		})
+		if !strings.HasSuffix(rendered, "\n"+test.rendered+"\n") {
+			t.Errorf("%s: unexpected rendered tree:\n%s", test.name, rendered)
+		}
+	}
+}
+
+// TestParseTextTree tests diagrams written by other tools or pasted into
+// documentation.
+func TestParseTextTree(t *testing.T) {
+	expected := ". dir=true\n" +
+		"cmd dir=true\n" +
+		"cmd/main.go dir=false\n" +
+		"docs dir=true\n" +
+		"go.mod dir=false\n" +
+		"latest dir=false -> cmd"
+
+	tests := map[string]string{
+		// GNU tree pads the bars with non-breaking spaces
+		"GNUTree": ".\n" +
+			"├── cmd\n" +
+			"│\u00a0\u00a0 └── main.go\n" +
+			"├── docs/\n" +
+			"├── go.mod\n" +
+			"└── latest -> cmd\n" +
+			"\n" +
+			"2 directories, 2 files\n",
+		"GNUTreeASCII": ".\n" +
+			"|-- cmd\n" +
+			"|   `-- main.go\n" +
+			"|-- docs/\n" +
+			"|-- go.mod\n" +
+			"`-- latest -> cmd\n" +
+			"\n" +
+			"2 directories, 2 files\n",
+		"MarkdownColors": "```\n" +
+			"  \x1b[34m./\x1b[0m\n" +
+			"  ├── \x1b[34mcmd/\x1b[0m\n" +
+			"  │   └── \x1b[32mmain.go\x1b[0m\n" +
+			"  ├── docs/ [error opening dir]\n" +
+			"  ├── go.mod\n" +
+			"  └── latest -> cmd\n" +
+			"```\n",
+	}
+	for name, input := range tests {
+		tree, err := ParseTextTree(strings.NewReader(input))
+		if err != nil {
+			t.Errorf("%s: ParseTextTree failed: %v", name, err)
+			continue
+		}
+		if got := strings.Join(flatten(tree), "\n"); got != expected {
+			t.Errorf("%s: unexpected tree:\nGot:\n%s\nExpected:\n%s", name, got, expected)
+		}
+	}
+
+	for input, expected := range map[string]string{
+		"\n\n":                  "the tree is empty",
+		"root/\nnot a branch\n": "line 2: no tree branch",
+		"root/\n├── a\n│   │   └── too-deep\n": "line 3: \"│   │   └── too-deep\" is indented deeper than its parent",
+	} {
+		_, err := ParseTextTree(strings.NewReader(input))
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("ParseTextTree(%q) = %v, expected an error containing %q", input, err, expected)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package printer
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+	"gopkg.in/yaml.v3"
+)
+
+// Theme holds the color spec of every element of the text output. Each value
+// uses the syntax of parseColor; an empty value leaves the element uncolored.
+type Theme struct {
+	Directory  string `yaml:"directory"`
+	File       string `yaml:"file"`
+	Executable string `yaml:"executable"`
+	Symlink    string `yaml:"symlink"`
+	Branch     string `yaml:"branch"`  // tree drawing characters
+	Summary    string `yaml:"summary"` // the "N directories, M files" line
+	Size       string `yaml:"size"`    // size column of --long
+	Date       string `yaml:"date"`    // time column of --long
+	Match      string `yaml:"match"`   // parts of names matched by pr find
+}
+
+// themes are the built-in themes selectable with --theme.
+var themes = map[string]Theme{
+	"default": {
+		Directory:  "blue",
+		File:       "green",
+		Executable: "red",
+		Symlink:    "cyan",
+		Size:       "yellow",
+		Date:       "magenta",
+		Match:      "bold+red",
+	},
+	"solarized": {
+		Directory:  "#268bd2",
+		File:       "#839496",
+		Executable: "#859900",
+		Symlink:    "#2aa198",
+		Branch:     "#586e75",
+		Summary:    "#b58900",
+		Size:       "#6c71c4",
+		Date:       "#2aa198",
+		Match:      "bold+#dc322f",
+	},
+	"monokai": {
+		Directory:  "#66d9ef",
+		File:       "#f8f8f2",
+		Executable: "#a6e22e",
+		Symlink:    "#ae81ff",
+		Branch:     "#75715e",
+		Summary:    "#e6db74",
+		Size:       "#fd971f",
+		Date:       "#75715e",
+		Match:      "bold+#f92672",
+	},
+	"high-contrast": {
+		Directory:  "bold+bright-blue",
+		File:       "bright-white",
+		Executable: "bold+bright-green",
+		Symlink:    "bold+bright-cyan",
+		Branch:     "white",
+		Summary:    "bold+bright-white",
+		Size:       "bold+bright-yellow",
+		Date:       "bright-white",
+		Match:      "reverse",
+	},
+	// Okabe-Ito colors, distinguishable with the common forms of color blindness.
+	"colorblind-safe": {
+		Directory:  "bold+#0072b2",
+		File:       "",
+		Executable: "#e69f00",
+		Symlink:    "#cc79a7",
+		Branch:     "gray",
+		Summary:    "#009e73",
+		Size:       "#56b4e9",
+		Date:       "#f0e442",
+		Match:      "bold+#d55e00",
+	},
+}
+
+// loadTheme returns a built-in theme by name, or reads a YAML theme file when
+// name ends in .yaml or .yml. An empty name selects the default theme.
+func loadTheme(name string) (Theme, error) {
+	if name == "" {
+		name = "default"
+	}
+
+	if strings.HasSuffix(name, ".yaml") || strings.HasSuffix(name, ".yml") {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return Theme{}, err
+		}
+		var theme Theme
+		if err := yaml.Unmarshal(data, &theme); err != nil {
+			return Theme{}, fmt.Errorf("parsing theme file %s: %w", name, err)
+		}
+		return theme, nil
+	}
+
+	theme, ok := themes[name]
+	if !ok {
+		return Theme{}, fmt.Errorf("unknown theme %q (available: %s, or a .yaml file)", name, strings.Join(themeNames(), ", "))
+	}
+	return theme, nil
+}
+
+// themeNames returns the sorted names of the built-in themes.
+func themeNames() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// colorEnabled decides whether the text printed to stdout is colorized.
+// --no-color and NO_COLOR always win; CLICOLOR_FORCE enables colors even
+// when stdout is not a terminal; otherwise colors are used on terminals only.
+func colorEnabled(config Config) bool {
+	if config.NoColor || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if force := os.Getenv("CLICOLOR_FORCE"); force != "" && force != "0" {
+		return true
+	}
+	if os.Getenv("CLICOLOR") == "0" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	fd := os.Stdout.Fd()
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
@@ -0,0 +1,80 @@
+package printer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// TestColorEnabled tests the environment variables that control colors.
+func TestColorEnabled(t *testing.T) {
+	tests := []struct {
+		name     string
+		noColor  bool
+		env      map[string]string
+		expected bool
+	}{
+		{"NotATerminal", false, nil, false},
+		{"Force", false, map[string]string{"CLICOLOR_FORCE": "1"}, true},
+		{"ForceZero", false, map[string]string{"CLICOLOR_FORCE": "0"}, false},
+		{"NoColorEnvWins", false, map[string]string{"CLICOLOR_FORCE": "1", "NO_COLOR": "1"}, false},
+		{"NoColorFlagWins", true, map[string]string{"CLICOLOR_FORCE": "1"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("NO_COLOR", "")
+			t.Setenv("CLICOLOR_FORCE", "")
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			// captureOutput swaps stdout for a pipe, which is never a terminal
+			var got bool
+			captureOutput(func() { got = colorEnabled(Config{NoColor: tt.noColor}) })
+			if got != tt.expected {
+				t.Errorf("colorEnabled() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestThemes tests rendering with a theme file and the built-in themes.
+func TestThemes(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("CLICOLOR_FORCE", "1")
+
+	themePath := filepath.Join(t.TempDir(), "mono.yaml")
+	data := "directory: bold\nbranch: \"240\"\nsummary: underline\n"
+	if err := os.WriteFile(themePath, []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write theme file: %v", err)
+	}
+
+	fsys := fstest.MapFS{"src/main.go": {}}
+	config := Config{OutputFormat: "text", SortBy: "name", Order: "asc", MaxDepth: -1, Theme: themePath}
+
+	output := captureOutput(func() { PrintFS(fsys, config) })
+
+	// Only the opening sequences are checked; the reset codes are up to the color package
+	for _, want := range []string{
+		"\x1b[38;5;240m└── ",
+		"\x1b[1msrc",
+		"\x1b[38;5;240m    └── ",
+		"\x1b[4m1 directories, 1 files",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected %q in output:\n%q", want, output)
+		}
+	}
+
+	for _, name := range themeNames() {
+		if _, err := newPalette(Config{Theme: name}); err != nil {
+			t.Errorf("Built-in theme %s is invalid: %v", name, err)
+		}
+	}
+
+	if _, err := newPalette(Config{Theme: "neon"}); err == nil {
+		t.Errorf("Expected an error for an unknown theme")
+	}
+}
@@ -0,0 +1,314 @@
+package printer
+
+import (
+	"fmt"
+	"hash/fnv"
+	"html"
+	"math"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Size of the drawing area of the svg-treemap and svg-sunburst formats.
+const (
+	chartWidth  = 1200
+	chartHeight = 800
+)
+
+// sizeIndex holds the aggregated size of every node: the file size for
+// files and the sum of the children for directories.
+type sizeIndex map[*Node]int64
+
+// newSizeIndex aggregates the sizes of the whole tree.
+func newSizeIndex(tree *Node) sizeIndex {
+	sizes := sizeIndex{}
+	var total func(*Node) int64
+	total = func(node *Node) int64 {
+		var size int64
+		if len(node.Children) == 0 && !node.IsDir && node.info != nil {
+			size = node.info.Size()
+		}
+		for _, child := range node.Children {
+			size += total(child)
+		}
+		sizes[node] = size
+		return size
+	}
+	total(tree)
+	return sizes
+}
+
+// children returns the children of node with a non-zero size, largest first.
+func (sizes sizeIndex) children(node *Node) []*Node {
+	var children []*Node
+	for _, child := range node.Children {
+		if sizes[child] > 0 {
+			children = append(children, child)
+		}
+	}
+	sort.SliceStable(children, func(i, j int) bool { return sizes[children[i]] > sizes[children[j]] })
+	return children
+}
+
+// rect is an axis-aligned rectangle of the treemap.
+type rect struct{ x, y, w, h float64 }
+
+// squarify lays out nodes, sorted largest first, in r so that each area is
+// proportional to its size, keeping the tiles as close to squares as
+// possible (Bruls, Huizing and van Wijk).
+func squarify(nodes []*Node, sizes sizeIndex, r rect) []rect {
+	var total float64
+	for _, node := range nodes {
+		total += float64(sizes[node])
+	}
+	if total == 0 || r.w <= 0 || r.h <= 0 {
+		return make([]rect, len(nodes))
+	}
+	areas := make([]float64, len(nodes))
+	for i, node := range nodes {
+		areas[i] = float64(sizes[node]) / total * r.w * r.h
+	}
+
+	// worst returns the highest aspect ratio of a row laid along a side of
+	// the given length.
+	worst := func(row []float64, side float64) float64 {
+		var sum, hi, lo float64 = 0, 0, math.Inf(1)
+		for _, a := range row {
+			sum += a
+			hi, lo = max(hi, a), min(lo, a)
+		}
+		return max(side*side*hi/(sum*sum), sum*sum/(side*side*lo))
+	}
+
+	tiles := make([]rect, 0, len(nodes))
+	layout := func(row []float64) {
+		var sum float64
+		for _, a := range row {
+			sum += a
+		}
+		if r.w >= r.h {
+			// A column along the left edge
+			width := sum / r.h
+			y := r.y
+			for _, a := range row {
+				tiles = append(tiles, rect{r.x, y, width, a / width})
+				y += a / width
+			}
+			r.x, r.w = r.x+width, r.w-width
+		} else {
+			// A row along the top edge
+			height := sum / r.w
+			x := r.x
+			for _, a := range row {
+				tiles = append(tiles, rect{x, r.y, a / height, height})
+				x += a / height
+			}
+			r.y, r.h = r.y+height, r.h-height
+		}
+	}
+
+	var row []float64
+	for i := 0; i < len(areas); {
+		side := min(r.w, r.h)
+		if len(row) == 0 || worst(append(row, areas[i]), side) <= worst(row, side) {
+			row = append(row, areas[i])
+			i++
+			continue
+		}
+		layout(row)
+		row = nil
+	}
+	if len(row) > 0 {
+		layout(row)
+	}
+	return tiles
+}
+
+// chartColors picks the fill color of files from their type or age.
+type chartColors struct {
+	by string // "type" or "age"
+}
+
+// newChartColors validates the --chart-color setting.
+func newChartColors(config Config) (chartColors, error) {
+	switch config.ChartColor {
+	case "", "type":
+		return chartColors{by: "type"}, nil
+	case "age":
+		return chartColors{by: "age"}, nil
+	}
+	return chartColors{}, fmt.Errorf("unknown chart color %q (available: type, age)", config.ChartColor)
+}
+
+// fill returns the CSS color of a node. Directories are neutral; files are
+// colored by a hue derived from their extension, or from green for recent
+// files to red for files five years old or more.
+func (c chartColors) fill(node *Node) string {
+	if node.IsDir {
+		return "#e8e8e8"
+	}
+	if c.by == "age" {
+		if node.info == nil || node.info.ModTime().IsZero() {
+			return "#bbbbbb"
+		}
+		days := max(now().Sub(node.info.ModTime()).Hours()/24, 0)
+		t := min(math.Log1p(days)/math.Log1p(5*365), 1)
+		return fmt.Sprintf("hsl(%.0f, 65%%, 55%%)", 120*(1-t))
+	}
+
+	ext := strings.ToLower(path.Ext(node.Name))
+	if ext == "" {
+		return "#bbbbbb"
+	}
+	h := fnv.New32a()
+	h.Write([]byte(ext))
+	return fmt.Sprintf("hsl(%d, 55%%, 60%%)", h.Sum32()%360)
+}
+
+// tooltip returns the hover text of a node: its path, or the name for the
+// root, and its size.
+func tooltip(node *Node, sizes sizeIndex) string {
+	name := node.Path
+	if name == "." {
+		name = node.Name
+	}
+	return fmt.Sprintf("%s (%s)", name, humanSize(sizes[node]))
+}
+
+// getTreemapSVG renders the tree as a squarified treemap where the area of
+// each rectangle is the aggregated size of the entry. Directories get a
+// header with their name when there is room for it.
+func getTreemapSVG(tree *Node, config Config) (string, error) {
+	colors, err := newChartColors(config)
+	if err != nil {
+		return "", err
+	}
+	sizes := newSizeIndex(tree)
+
+	const header = 16 // height of directory name bars
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\" font-family=\"sans-serif\" font-size=\"11\">\n",
+		chartWidth, chartHeight, chartWidth, chartHeight)
+	fmt.Fprintf(&sb, "<title>%s</title>\n", html.EscapeString(tooltip(tree, sizes)))
+
+	var draw func(node *Node, r rect)
+	draw = func(node *Node, r rect) {
+		if r.w < 1 || r.h < 1 {
+			return
+		}
+		fmt.Fprintf(&sb, "<g><title>%s</title><rect x=\"%.1f\" y=\"%.1f\" width=\"%.1f\" height=\"%.1f\" fill=\"%s\" stroke=\"#ffffff\"/>",
+			html.EscapeString(tooltip(node, sizes)), r.x, r.y, r.w, r.h, colors.fill(node))
+		label := node.Name
+		if node.IsDir {
+			label += "/"
+		}
+		// Labels are only drawn when they roughly fit
+		if r.h >= 14 && float64(len(label))*6.5+6 <= r.w {
+			fmt.Fprintf(&sb, "<text x=\"%.1f\" y=\"%.1f\">%s</text>", r.x+3, r.y+12, html.EscapeString(label))
+		}
+		sb.WriteString("</g>\n")
+
+		if !node.IsDir {
+			return
+		}
+		inner := rect{r.x + 2, r.y + 2, r.w - 4, r.h - 4}
+		if r.h > 3*header {
+			inner.y, inner.h = r.y+header, r.h-header-2
+		}
+		children := sizes.children(node)
+		for i, tile := range squarify(children, sizes, inner) {
+			draw(children[i], tile)
+		}
+	}
+	if sizes[tree] > 0 {
+		draw(tree, rect{0, 0, chartWidth, chartHeight})
+	} else {
+		fmt.Fprintf(&sb, "<text x=\"10\" y=\"20\">%s is empty</text>\n", html.EscapeString(tree.Name))
+	}
+	sb.WriteString("</svg>\n")
+	return sb.String(), nil
+}
+
+// getSunburstSVG renders the tree as a sunburst: the root is the center and
+// every level is a ring whose arcs are proportional to the aggregated sizes.
+func getSunburstSVG(tree *Node, config Config) (string, error) {
+	colors, err := newChartColors(config)
+	if err != nil {
+		return "", err
+	}
+	sizes := newSizeIndex(tree)
+
+	depth := 0
+	var measure func(*Node, int)
+	measure = func(node *Node, d int) {
+		depth = max(depth, d)
+		for _, child := range node.Children {
+			measure(child, d+1)
+		}
+	}
+	measure(tree, 0)
+
+	cx, cy := float64(chartWidth)/2, float64(chartHeight)/2
+	radius := min(cx, cy) - 10
+	ring := radius / float64(depth+1)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"0 0 %d %d\" font-family=\"sans-serif\" font-size=\"11\">\n",
+		chartWidth, chartHeight, chartWidth, chartHeight)
+	fmt.Fprintf(&sb, "<g><title>%s</title><circle cx=\"%.1f\" cy=\"%.1f\" r=\"%.1f\" fill=\"#e8e8e8\"/></g>\n",
+		html.EscapeString(tooltip(tree, sizes)), cx, cy, ring)
+
+	point := func(r, angle float64) (float64, float64) {
+		return cx + r*math.Sin(angle), cy - r*math.Cos(angle)
+	}
+	var draw func(node *Node, start, end float64, level int)
+	draw = func(node *Node, start, end float64, level int) {
+		total := float64(sizes[node])
+		for _, child := range sizes.children(node) {
+			span := (end - start) * float64(sizes[child]) / total
+			// Arcs too thin to see or hover are left out
+			if span >= 0.002 {
+				inner, outer := ring*float64(level), ring*float64(level+1)
+				large := 0
+				if span > math.Pi {
+					large = 1
+				}
+				x1, y1 := point(outer, start)
+				x2, y2 := point(outer, start+span)
+				x3, y3 := point(inner, start+span)
+				x4, y4 := point(inner, start)
+				fmt.Fprintf(&sb, "<g><title>%s</title><path d=\"M%.1f %.1fA%.1f %.1f 0 %d 1 %.1f %.1fL%.1f %.1fA%.1f %.1f 0 %d 0 %.1f %.1fZ\" fill=\"%s\" stroke=\"#ffffff\"/></g>\n",
+					html.EscapeString(tooltip(child, sizes)), x1, y1, outer, outer, large, x2, y2, x3, y3, inner, inner, large, x4, y4, colors.fill(child))
+				draw(child, start, start+span, level+1)
+			}
+			start += span
+		}
+	}
+	if sizes[tree] > 0 {
+		draw(tree, 0, 2*math.Pi*0.999999, 1)
+	}
+	sb.WriteString("</svg>\n")
+	return sb.String(), nil
+}
+
+// getTreemapHTML wraps the treemap in a self-contained HTML page. Hovering a
+// rectangle shows its path and size.
+func getTreemapHTML(tree *Node, config Config) (string, error) {
+	svg, err := getTreemapSVG(tree, config)
+	if err != nil {
+		return "", err
+	}
+	title := html.EscapeString(tree.Name)
+	total := humanSize(newSizeIndex(tree)[tree])
+
+	var sb strings.Builder
+	sb.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(&sb, "<title>%s</title>\n", title)
+	sb.WriteString("<style>body{font-family:sans-serif;margin:16px}g:hover>rect{stroke:#000}</style>\n")
+	sb.WriteString("</head>\n<body>\n")
+	fmt.Fprintf(&sb, "<h1>%s (%s)</h1>\n", title, total)
+	sb.WriteString(svg)
+	sb.WriteString("</body>\n</html>\n")
+	return sb.String(), nil
+}
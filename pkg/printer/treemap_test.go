@@ -0,0 +1,104 @@
+package printer
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// TestSquarify tests that the treemap tiles fill the area in proportion to
+// the sizes.
+func TestSquarify(t *testing.T) {
+	fsys := fstest.MapFS{}
+	for name, size := range map[string]int{"a": 6000, "b": 6000, "c": 4000, "d": 3000, "e": 2000, "f": 2000, "g": 1000} {
+		fsys[name] = &fstest.MapFile{Data: make([]byte, size)}
+	}
+	tree, err := BuildTree(fsys, Config{MaxDepth: -1})
+	if err != nil {
+		t.Fatalf("BuildTree failed: %v", err)
+	}
+
+	sizes := newSizeIndex(tree)
+	if sizes[tree] != 24000 {
+		t.Fatalf("Root size is %d, expected 24000", sizes[tree])
+	}
+	children := sizes.children(tree)
+	tiles := squarify(children, sizes, rect{0, 0, 6, 4})
+	for i, tile := range tiles {
+		expected := float64(sizes[children[i]]) / 1000
+		if math.Abs(tile.w*tile.h-expected) > 1e-9 {
+			t.Errorf("%s has area %f, expected %f", children[i].Name, tile.w*tile.h, expected)
+		}
+		if tile.x < 0 || tile.y < 0 || tile.x+tile.w > 6+1e-9 || tile.y+tile.h > 4+1e-9 {
+			t.Errorf("%s is outside the area: %+v", children[i].Name, tile)
+		}
+	}
+}
+
+// TestCharts tests the treemap and sunburst formats.
+func TestCharts(t *testing.T) {
+	fsys := fstest.MapFS{
+		"src/main.go":  {Data: make([]byte, 3000)},
+		"src/<b>.go":   {Data: make([]byte, 1000)},
+		"logo.png":     {Data: make([]byte, 4000)},
+		"empty/.keep":  {},
+		"docs/notes.m": {Data: make([]byte, 500)},
+	}
+	config := Config{SortBy: "name", Order: "asc", MaxDepth: -1}
+
+	t.Run("Treemap", func(t *testing.T) {
+		config := config
+		config.OutputFormat = "svg-treemap"
+		config.IncludeHidden = true
+		output := captureOutput(func() { PrintFS(fsys, config) })
+
+		if !strings.HasPrefix(output, "<svg xmlns=\"http://www.w3.org/2000/svg\"") {
+			t.Fatalf("Expected an SVG document, got:\n%s", output)
+		}
+		for _, want := range []string{"<title>src/main.go (2.9K)</title>", "<title>src/&lt;b&gt;.go (1000)</title>", "<title>logo.png (3.9K)</title>"} {
+			if !strings.Contains(output, want) {
+				t.Errorf("Expected %q in output:\n%s", want, output)
+			}
+		}
+		// Empty files and directories take no space
+		if strings.Contains(output, ".keep") || strings.Contains(output, "<title>empty") {
+			t.Errorf("Expected empty entries to be left out:\n%s", output)
+		}
+		if n := strings.Count(output, "<rect"); n != 7 {
+			t.Errorf("Expected 7 rectangles, got %d", n)
+		}
+	})
+
+	t.Run("HTML", func(t *testing.T) {
+		config := config
+		config.OutputFormat = "html-treemap"
+		output := captureOutput(func() { PrintFS(fsys, config) })
+
+		if !strings.HasPrefix(output, "<!DOCTYPE html>") || !strings.Contains(output, "<h1>. (8.3K)</h1>") || !strings.Contains(output, "<svg") {
+			t.Errorf("Unexpected output:\n%s", output)
+		}
+	})
+
+	t.Run("Sunburst", func(t *testing.T) {
+		config := config
+		config.OutputFormat = "svg-sunburst"
+		config.ChartColor = "age"
+		output := captureOutput(func() { PrintFS(fsys, config) })
+
+		if n := strings.Count(output, "<path"); n != 6 {
+			t.Errorf("Expected 6 arcs, got %d:\n%s", n, output)
+		}
+	})
+
+	t.Run("UnknownColor", func(t *testing.T) {
+		config := config
+		config.OutputFormat = "svg-treemap"
+		config.ChartColor = "owner"
+		output := captureOutput(func() { PrintFS(fsys, config) })
+
+		if !strings.HasPrefix(output, "Error: unknown chart color \"owner\"") {
+			t.Errorf("Expected an unknown chart color error, got:\n%s", output)
+		}
+	})
+}
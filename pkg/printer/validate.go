@@ -0,0 +1,50 @@
+package printer
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// outputFormats are the values of Config.OutputFormat.
+var outputFormats = []string{"text", "json", "xml", "yaml", "markdown", "html", "list", "csv", "tsv", "dot", "mermaid", "svg-treemap", "html-treemap", "svg-sunburst"}
+
+// ValidateConfig reports settings that are wrong whatever the tree holds,
+// such as an unknown format, style, icon set, theme or color. Every setting
+// is checked, including ones the output format does not use, so typos are
+// found before a long walk. HandleFlags and PrintFS call it first.
+func ValidateConfig(config Config) error {
+	if !slices.Contains(outputFormats, config.OutputFormat) {
+		return fmt.Errorf("unsupported format %q (available: %s)", config.OutputFormat, strings.Join(outputFormats, ", "))
+	}
+	if _, err := getTreeStyle(config); err != nil {
+		return err
+	}
+	if _, err := iconSet(config.IconSet); err != nil {
+		return err
+	}
+	if _, err := newPalette(config); err != nil {
+		return err
+	}
+	if err := checkListFields(config.Fields); err != nil {
+		return err
+	}
+	if err := checkTimeStyle(config.TimeStyle); err != nil {
+		return err
+	}
+	if _, err := newChartColors(config); err != nil {
+		return err
+	}
+	if _, err := newEntrySorter(config); err != nil {
+		return err
+	}
+	if _, err := newPredicates(config); err != nil {
+		return err
+	}
+	if config.Where != "" {
+		if _, err := CompileFilter(config.Where); err != nil {
+			return fmt.Errorf("--where: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package printer
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// TestValidateConfig tests that invalid settings are reported for every
+// format, before anything is read.
+func TestValidateConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		expected string
+	}{
+		{"Format", Config{OutputFormat: "pdf"}, `unsupported format "pdf"`},
+		{"DirColor", Config{OutputFormat: "list", DirColor: "bleu"}, `directory color: unknown color "bleu"`},
+		{"Style", Config{OutputFormat: "json", Style: "bogus"}, `unknown style "bogus"`},
+		{"Theme", Config{OutputFormat: "json", Theme: "nope"}, `unknown theme "nope"`},
+		{"IconSet", Config{OutputFormat: "yaml", IconSet: "wingdings"}, `unknown icon set "wingdings"`},
+		{"Fields", Config{OutputFormat: "text", Fields: []string{"inode"}}, `unknown field "inode"`},
+		{"ChartColor", Config{OutputFormat: "xml", ChartColor: "owner"}, `unknown chart color "owner"`},
+		{"SortBy", Config{OutputFormat: "text", SortBy: "colour"}, `--sort-by: unknown key "colour"`},
+		{"Order", Config{OutputFormat: "json", Order: "up"}, `--order: unknown order "up"`},
+		{"Collate", Config{OutputFormat: "text", Collate: "not a language"}, `--collate: invalid language`},
+		{"MinSize", Config{OutputFormat: "csv", MinSize: "10Q"}, `--min-size: `},
+		{"NewerThan", Config{OutputFormat: "text", NewerThan: "yesterday"}, `--newer-than: `},
+		{"OlderThan", Config{OutputFormat: "json", OlderThan: "2025-13-01"}, `--older-than: `},
+		{"Type", Config{OutputFormat: "text", Types: "f,q"}, `--type: unknown type "q"`},
+		{"TimeStyle", Config{OutputFormat: "json", Long: true, TimeStyle: "bogus"}, `unknown time style "bogus" (available: iso, relative, locale, ls)`},
+		{"Where", Config{OutputFormat: "json", Where: "size > 10Q"}, `--where: invalid filter "size > 10Q": at column 8: "10Q" is not a size`},
+	}
+	for _, test := range tests {
+		err := ValidateConfig(test.config)
+		if err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("%s: got %v, expected an error containing %q", test.name, err, test.expected)
+		}
+
+		// The config is rejected before the missing directory is opened
+		config := test.config
+		config.DirPath = filepath.Join(t.TempDir(), "missing")
+		var handleErr error
+		output := captureOutput(func() { handleErr = HandleFlagsContext(context.Background(), config) })
+		if handleErr == nil || !strings.Contains(handleErr.Error(), test.expected) || output != "" {
+			t.Errorf("%s: HandleFlagsContext returned %v and printed %q", test.name, handleErr, output)
+		}
+	}
+
+	if err := ValidateConfig(Config{OutputFormat: "json", Style: "ascii", IconSet: "emoji", Theme: "solarized"}); err != nil {
+		t.Errorf("Expected a valid config, got %v", err)
+	}
+
+	output := captureOutput(func() { PrintFS(fstest.MapFS{"a": {}}, Config{OutputFormat: "csv", Style: "bogus"}) })
+	if !strings.HasPrefix(output, `Error: unknown style "bogus"`) {
+		t.Errorf("Expected PrintFS to report the style, got %q", output)
+	}
+}